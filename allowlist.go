@@ -0,0 +1,52 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"errors"
+	"fmt"
+	"path"
+)
+
+// ErrNameNotAllowed is wrapped by the error Send returns for a parameter
+// outside the name allowlist, given WithRejectDisallowedNames.
+var ErrNameNotAllowed = errors.New("ssmconfig: parameter name not in allowlist")
+
+func validateAllowlist(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid name allowlist pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// allowed reports whether Send should apply the fetched parameter name. It
+// returns an error instead of false if disallowed names are to be rejected.
+func (r *request) allowed(name string) (bool, error) {
+	// Only an allowlist never given is nil; an empty one allows nothing.
+	if r.config.nameAllowlist == nil {
+		return true, nil
+	}
+	for _, pattern := range r.config.nameAllowlist {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true, nil
+		}
+	}
+	if r.config.rejectDisallowed {
+		return false, fmt.Errorf("%w: %s", ErrNameNotAllowed, name)
+	}
+	return false, nil
+}
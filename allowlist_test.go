@@ -0,0 +1,61 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithNameAllowlist(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/HasTags/Foo":         "foo",
+		"/HasTags/OptionalBar": "bar",
+	}}
+	allowFoo := WithNameAllowlist([]string{"/HasTags/F*"})
+
+	var v hasTags
+	if err := NewRequest(&v, "/HasTags", client, allowFoo).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo" || v.OptionalBar != "" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	v = hasTags{}
+	err := NewRequest(&v, "/HasTags", client, WithNameAllowlist([]string{"/Other/*"})).Send(context.Background())
+	var missing MissingParameters
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/HasTags/Foo"}) {
+		t.Fatalf("expected disallowed /HasTags/Foo to be missing, got %v", err)
+	}
+
+	v = hasTags{}
+	err = NewRequest(&v, "/HasTags", client, allowFoo, WithRejectDisallowedNames()).Send(context.Background())
+	if !errors.Is(err, ErrNameNotAllowed) || v.OptionalBar != "" {
+		t.Fatalf("expected ErrNameNotAllowed, got %v (%+v)", err, v)
+	}
+
+	v = hasTags{}
+	err = NewRequest(&v, "/HasTags", client, WithNameAllowlist(nil)).Send(context.Background())
+	if !errors.As(err, &missing) || v.Foo != "" || v.OptionalBar != "" {
+		t.Fatalf("expected an empty allowlist to allow nothing, got %v (%+v)", err, v)
+	}
+
+	if _, err := TryNewRequest(&v, "/HasTags", client, WithNameAllowlist([]string{"/HasTags/["})); err == nil {
+		t.Fatal("expected error for malformed pattern")
+	}
+}
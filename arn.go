@@ -0,0 +1,159 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// GetParametersAPIClient is the subset of *ssm.Client used to fetch
+// parameters by ARN, as for those shared from another account. A base path
+// or tag given as an ARN, such as
+//
+//	arn:aws:ssm:us-east-1:123456789012:parameter/shared/db
+//
+// can't be fetched by path, so Send fetches its parameters with one
+// GetParameters call per 10 of them.
+type GetParametersAPIClient interface {
+	GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
+}
+
+var _ GetParametersAPIClient = (*ssm.Client)(nil)
+
+// getParametersBatchSize is the most names GetParameters accepts.
+const getParametersBatchSize = 10
+
+func isARN(name string) bool {
+	return strings.HasPrefix(name, "arn:")
+}
+
+// bindARNs routes the names given as ARNs to the fetch by name.
+func (r *request) bindARNs(path string) error {
+	if isARN(path) && r.collectsUnmatched() {
+		return errors.New("interface, indexed and map fields can't be bound under an ARN, which can't be fetched by path")
+	}
+	for name := range r.setters {
+		if isARN(name) {
+			r.byName[name] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// checkByNameClient returns an error if the client can't fetch the names
+// fetched by name.
+func (r *request) checkByNameClient() error {
+	if r.client == nil {
+		return nil
+	}
+	if r.config.jsonBundle != "" {
+		_, single := r.client.(GetParameterAPIClient)
+		_, batch := r.client.(GetParametersAPIClient)
+		if !single && !batch {
+			return errors.New("client must implement GetParameter or GetParameters to fetch a JSON bundle")
+		}
+	}
+	if _, ok := r.client.(GetParameterAPIClient); !ok && r.config.isolateSecure && len(r.secure) > 0 {
+		return errors.New("client must implement GetParameter to isolate the fetches of secure fields")
+	}
+	for name := range r.byName {
+		if isARN(name) {
+			if _, ok := r.client.(GetParametersAPIClient); !ok {
+				return errors.New("client must implement GetParameters to fetch parameters by ARN")
+			}
+			continue
+		}
+		_, single := r.client.(GetParameterAPIClient)
+		_, batch := r.client.(GetParametersAPIClient)
+		if !single && !batch {
+			return errors.New("client must implement GetParameter or GetParameters to fetch fields by name")
+		}
+	}
+	return nil
+}
+
+// fetchARNs fetches the names given as ARNs.
+func (r *request) fetchARNs(ctx context.Context, parseErrors ParseErrors) (ParseErrors, error) {
+	var names []string
+	for _, name := range sortedNames(r.byName) {
+		if isARN(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return parseErrors, nil
+	}
+	parameters, err := r.getParameters(ctx, r.client.(GetParametersAPIClient), names)
+	if err != nil {
+		return parseErrors, err
+	}
+	return r.applyOrDefer(parameters, nil, nil, parseErrors)
+}
+
+// getParameters fetches names with GetParameters, batched by whether
+// they're decrypted, and returns those found named as requested.
+func (r *request) getParameters(ctx context.Context, client GetParametersAPIClient, names []string) ([]types.Parameter, error) {
+	var batches [2][]string
+	for _, name := range names {
+		if aws.ToBool(r.withDecryption(name)) {
+			batches[1] = append(batches[1], name)
+		} else {
+			batches[0] = append(batches[0], name)
+		}
+	}
+
+	var parameters []types.Parameter
+	for decrypt, names := range batches {
+		for start := 0; start < len(names); start += getParametersBatchSize {
+			end := start + getParametersBatchSize
+			if end > len(names) {
+				end = len(names)
+			}
+			batch := names[start:end]
+			output, err := client.GetParameters(ctx, &ssm.GetParametersInput{
+				Names:          batch,
+				WithDecryption: aws.Bool(decrypt == 1),
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			requested := make(map[string]struct{}, len(batch))
+			for _, name := range batch {
+				requested[name] = struct{}{}
+			}
+			for _, parameter := range output.Parameters {
+				// SSM may return the ARN or the plain name of a shared
+				// parameter; apply it under the ARN it was bound to.
+				name := aws.ToString(parameter.ARN)
+				if _, ok := requested[name]; !ok {
+					name = normalizeName(aws.ToString(parameter.Name))
+				}
+				if _, ok := requested[name]; !ok {
+					continue
+				}
+				parameter.Name = aws.String(name)
+				parameters = append(parameters, parameter)
+			}
+		}
+	}
+	return parameters, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+const sharedARN = "arn:aws:ssm:us-east-1:123456789012:parameter/shared"
+
+// sharingClient answers GetParameters for the ARNs of its parameters,
+// returning their plain names as SSM does for shared parameters.
+type sharingClient struct {
+	fakeClient
+	shared   map[string]string
+	batches  [][]string
+	pathUsed bool
+}
+
+func (c *sharingClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	c.pathUsed = true
+	return c.fakeClient.GetParametersByPath(ctx, params, optFns...)
+}
+
+func (c *sharingClient) GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	c.batches = append(c.batches, params.Names)
+	var output ssm.GetParametersOutput
+	for _, arn := range params.Names {
+		value, ok := c.shared[arn]
+		if !ok {
+			output.InvalidParameters = append(output.InvalidParameters, arn)
+			continue
+		}
+		output.Parameters = append(output.Parameters, types.Parameter{
+			ARN:   aws.String(arn),
+			Name:  aws.String(arn[strings.Index(arn, ":parameter")+len(":parameter"):]),
+			Value: aws.String(value),
+			Type:  types.ParameterTypeString,
+		})
+	}
+	return &output, nil
+}
+
+func TestARNTag(t *testing.T) {
+	client := &sharingClient{
+		fakeClient: fakeClient{parameters: map[string]string{"/app/Local": "local"}},
+		shared:     map[string]string{sharedARN + "/db": "postgres://shared"},
+	}
+	var v struct {
+		Local  string `ssm:"Local"`
+		Shared string `ssm:"arn:aws:ssm:us-east-1:123456789012:parameter/shared/db"`
+		Absent string `ssm:"arn:aws:ssm:us-east-1:123456789012:parameter/shared/absent,optional"`
+	}
+	req := NewRequest(&v, "/app", client)
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Local != "local" || v.Shared != "postgres://shared" {
+		t.Errorf("unexpected result: %+v", v)
+	}
+	want := [][]string{{sharedARN + "/absent", sharedARN + "/db"}}
+	if !reflect.DeepEqual(client.batches, want) {
+		t.Errorf("got GetParameters batches %v, want %v", client.batches, want)
+	}
+}
+
+func TestARNPath(t *testing.T) {
+	client := &sharingClient{shared: map[string]string{sharedARN + "/Foo": "foo"}}
+	var v hasTags
+	req := NewRequest(&v, sharedARN, client)
+	if got, want := req.ParameterNames(), []string{sharedARN + "/Foo", sharedARN + "/OptionalBar"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got names %v, want %v", got, want)
+	}
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo" {
+		t.Errorf("got Foo %q, want foo", v.Foo)
+	}
+	if client.pathUsed {
+		t.Error("fetched an ARN path by path")
+	}
+
+	if _, err := TryNewRequest(&v, sharedARN, &fakeClient{}); err == nil {
+		t.Error("expected an error for a client without GetParameters")
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// sendWithinBudget sends the request under the deadline of WithTotalBudget,
+// if any.
+func (r *request) sendWithinBudget(ctx context.Context) error {
+	if r.config.budget <= 0 {
+		return r.send(ctx)
+	}
+	r.deadline = time.Now().Add(r.config.budget)
+	budgetCtx, cancel := context.WithDeadline(ctx, r.deadline)
+	defer cancel()
+	err := r.send(budgetCtx)
+
+	var exceeded *BudgetExceededError
+	if err == nil || errors.As(err, &exceeded) {
+		return err
+	}
+	if ctx.Err() == nil && errors.Is(budgetCtx.Err(), context.DeadlineExceeded) {
+		return &BudgetExceededError{Budget: r.config.budget, Err: err}
+	}
+	return err
+}
+
+// overBudget reports whether waiting d would overrun the budget of
+// WithTotalBudget.
+func (r *request) overBudget(d time.Duration) bool {
+	return r.config.budget > 0 && !r.deadline.IsZero() && time.Now().Add(d).After(r.deadline)
+}
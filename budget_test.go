@@ -0,0 +1,58 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithTotalBudget(t *testing.T) {
+	start := time.Now()
+	err := NewRequest(&hasTags{}, "/app", blockingClient{}, WithTotalBudget(10*time.Millisecond)).Send(context.Background())
+	var exceeded *BudgetExceededError
+	var canceled *CanceledError
+	if !errors.As(err, &exceeded) || !errors.As(err, &canceled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a *BudgetExceededError wrapping a *CanceledError, got %v", err)
+	}
+	if exceeded.Budget != 10*time.Millisecond || time.Since(start) > time.Second {
+		t.Errorf("unexpected budget %v after %v", exceeded.Budget, time.Since(start))
+	}
+
+	// A retry that can't start within the budget isn't waited for.
+	start = time.Now()
+	err = NewRequest(&hasTags{}, "/app", &fakeClient{}, WithTotalBudget(time.Second), WithMissingRetry(3, time.Hour)).Send(context.Background())
+	var missing *MissingFieldsError
+	if !errors.As(err, &exceeded) || !errors.As(err, &missing) {
+		t.Fatalf("expected a *BudgetExceededError wrapping the missing parameters, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("waited %v for a retry past the budget", time.Since(start))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = NewRequest(&hasTags{}, "/app", blockingClient{}, WithTotalBudget(time.Hour)).Send(ctx)
+	if errors.As(err, &exceeded) || !errors.As(err, &canceled) {
+		t.Fatalf("expected the caller's deadline not to count against the budget, got %v", err)
+	}
+
+	client := &fakeClient{parameters: map[string]string{"/app/Foo": "foo"}}
+	if err := NewRequest(&hasTags{}, "/app", client, WithTotalBudget(time.Second)).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+// Builder builds Requests that share a client and options, so options that
+// apply to every Request, such as WithThrottleRetry or WithTracer, are given
+// once.
+type Builder struct {
+	client PathFetcher
+	opts   []Option
+}
+
+// New returns a Builder of Requests that fetch with client, built with opts.
+func New(client PathFetcher, opts ...Option) *Builder {
+	return &Builder{client: client, opts: opts}
+}
+
+// Request is like NewRequest with the client and options of b. opts are
+// applied after those of b, so they override them.
+func (b *Builder) Request(configurable interface{}, path string, opts ...Option) Request {
+	return NewRequest(configurable, path, b.client, b.options(opts)...)
+}
+
+// TryRequest is like TryNewRequest with the client and options of b.
+func (b *Builder) TryRequest(configurable interface{}, path string, opts ...Option) (Request, error) {
+	return TryNewRequest(configurable, path, b.client, b.options(opts)...)
+}
+
+func (b *Builder) options(opts []Option) []Option {
+	// Copied so that Requests never share the backing array of b.opts.
+	all := make([]Option, 0, len(b.opts)+len(opts))
+	all = append(all, b.opts...)
+	return append(all, opts...)
+}
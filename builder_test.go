@@ -0,0 +1,51 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/db/host":   "db.internal",
+		"/cache/url": "redis://cache",
+	}}
+	b := New(client, WithNameSuffix("-unused"))
+	var db struct {
+		Host string `ssm:"host"`
+	}
+	var cache struct {
+		URL string `ssm:"url"`
+	}
+	dbRequest := b.Request(&db, "/db", WithNameSuffix(""))
+	cacheRequest, err := b.TryRequest(&cache, "/cache", WithNameSuffix(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := MergeRequests([]Request{dbRequest, cacheRequest}).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if db.Host != "db.internal" || cache.URL != "redis://cache" {
+		t.Fatalf("unexpected result: %+v %+v", db, cache)
+	}
+
+	var v hasTags
+	if names := b.Request(&v, "/app").ParameterNames(); !reflect.DeepEqual(names, []string{"/app/Foo-unused", "/app/OptionalBar-unused"}) {
+		t.Fatalf("expected the options of the builder to apply, got %v", names)
+	}
+}
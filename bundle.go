@@ -0,0 +1,85 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// fetchBundle fetches the parameter given to WithJSONBundle and applies each
+// of its keys as the parameter it names under the base path.
+func (r *request) fetchBundle(ctx context.Context, unmatched map[string]string, parseErrors ParseErrors) (ParseErrors, error) {
+	bundle, err := r.getBundle(ctx)
+	if err != nil || bundle == nil {
+		return parseErrors, err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(aws.ToString(bundle.Value)), &values); err != nil {
+		return append(parseErrors, &ParseError{Name: r.config.jsonBundle, Err: err}), nil
+	}
+
+	path := normalizeName(aws.ToString(r.input.Path))
+	parameters := make([]types.Parameter, 0, len(values))
+	for key, raw := range values {
+		name := r.config.suffixed(joinName(path, key))
+		value := string(raw)
+		if bytes.HasPrefix(raw, []byte(`"`)) {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				parseErrors = append(parseErrors, &ParseError{Name: name, Err: err})
+				continue
+			}
+		}
+		parameters = append(parameters, types.Parameter{
+			Name:    aws.String(name),
+			Value:   aws.String(value),
+			Type:    bundle.Type,
+			Version: bundle.Version,
+		})
+	}
+	return r.applyOrDefer(parameters, nil, unmatched, parseErrors)
+}
+
+// getBundle fetches the parameter given to WithJSONBundle, or nil if it
+// doesn't exist.
+func (r *request) getBundle(ctx context.Context) (*types.Parameter, error) {
+	name := r.config.jsonBundle
+	client, ok := r.client.(GetParameterAPIClient)
+	if !ok {
+		parameters, err := r.getParameters(ctx, r.client.(GetParametersAPIClient), []string{name})
+		if err != nil || len(parameters) == 0 {
+			return nil, err
+		}
+		return &parameters[0], nil
+	}
+	output, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           &name,
+		WithDecryption: r.withDecryption(name),
+	})
+	var notFound *types.ParameterNotFound
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return output.Parameter, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithJSONBundle(t *testing.T) {
+	type config struct {
+		Host    string   `ssm:"db/host"`
+		Port    int      `ssm:"db/port"`
+		Debug   bool     `ssm:"Debug"`
+		Hosts   []string `ssm:"Hosts,optional"`
+		Missing string   `ssm:"Missing,optional"`
+	}
+	client := &recordingClient{fakeClient: fakeClient{parameters: map[string]string{
+		"/app/bundle":  `{"db/host": "db.internal", "db/port": 5432, "Debug": true, "Hosts": "a,b", "Other": {"x": 1}}`,
+		"/app/db/host": "not from the bundle",
+	}}}
+	var v config
+	if err := NewRequest(&v, "/app", client, WithJSONBundle("/app/bundle")).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expected := config{Host: "db.internal", Port: 5432, Debug: true, Hosts: []string{"a", "b"}}
+	if !reflect.DeepEqual(v, expected) || len(client.inputs) != 0 {
+		t.Fatalf("unexpected result: %+v after %d fetches by path", v, len(client.inputs))
+	}
+
+	client.parameters["/app/bundle"] = `{"db/host": "db.internal", "db/port": "many"}`
+	err := NewRequest(&config{}, "/app", client, WithJSONBundle("/app/bundle")).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 1 || parseErrors[0].Name != "/app/db/port" {
+		t.Fatalf("expected a ParseError for /app/db/port, got %v", err)
+	}
+
+	client.parameters["/app/bundle"] = `{"db/host": "db.internal"}`
+	err = NewRequest(&config{}, "/app", client, WithJSONBundle("/app/bundle")).Send(context.Background())
+	var missing MissingParameters
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/app/Debug", "/app/db/port"}) {
+		t.Fatalf("expected the keys absent from the bundle to be missing, got %v", err)
+	}
+
+	client.parameters["/app/bundle"] = `not json`
+	err = NewRequest(&config{}, "/app", client, WithJSONBundle("/app/bundle")).Send(context.Background())
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/app/bundle" {
+		t.Fatalf("expected a ParseError for /app/bundle, got %v", err)
+	}
+
+	delete(client.parameters, "/app/bundle")
+	err = NewRequest(&config{}, "/app", client, WithJSONBundle("/app/bundle")).Send(context.Background())
+	if !errors.As(err, &missing) || len(missing) != 3 {
+		t.Fatalf("expected every required field to be missing, got %v", err)
+	}
+
+	pathOnly := struct{ PathFetcher }{&client.fakeClient}
+	if _, err := TryNewRequest(&config{}, "/app", pathOnly, WithJSONBundle("/app/bundle")); err == nil {
+		t.Error("expected an error for a client without GetParameter")
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// GetParameterAPIClient is the subset of *ssm.Client used to fetch fields
+// bound to a single parameter by name, such as those tagged `ssm:",self"`,
+// `ssm:"feature,inlist=/app/features"` or `ssm:"Region,path=/common/region"`.
+// Each such parameter costs Send one GetParameter call in addition to the
+// fetch by path, or one GetParameters call per 10 of them if the client
+// implements GetParametersAPIClient too.
+type GetParameterAPIClient interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+var _ GetParameterAPIClient = (*ssm.Client)(nil)
+
+// needsPathFetch reports whether any setter is left for GetParametersByPath
+// once the names fetched individually are accounted for.
+func (r *request) needsPathFetch() bool {
+	if r.collectsUnmatched() {
+		return true
+	}
+	for name := range r.setters {
+		_, byName := r.byName[name]
+		_, external := r.external[name]
+		if !byName && !external {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *request) fetchByName(ctx context.Context, parseErrors ParseErrors) (ParseErrors, error) {
+	if batcher, ok := r.client.(GetParametersAPIClient); ok {
+		parameters, err := r.getParameters(ctx, batcher, sortedNames(r.byName))
+		if err != nil {
+			return parseErrors, err
+		}
+		return r.applyOrDefer(parameters, nil, nil, parseErrors)
+	}
+
+	client := r.client.(GetParameterAPIClient)
+	for _, name := range sortedNames(r.byName) {
+		if isARN(name) {
+			continue
+		}
+		output, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+			Name:           &name,
+			WithDecryption: r.withDecryption(name),
+		})
+		var notFound *types.ParameterNotFound
+		if errors.As(err, &notFound) {
+			continue
+		}
+		if err != nil {
+			return parseErrors, err
+		}
+		parameter := *output.Parameter
+		parameter.Name = aws.String(name)
+		if parseErrors, err = r.applyOrDefer([]types.Parameter{parameter}, nil, nil, parseErrors); err != nil {
+			return parseErrors, err
+		}
+	}
+	return r.fetchARNs(ctx, parseErrors)
+}
@@ -0,0 +1,134 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestSelf(t *testing.T) {
+	client := &recordingClient{fakeClient: fakeClient{parameters: map[string]string{
+		"/app/token": "secret",
+	}}}
+	var v struct {
+		Token string `ssm:",self"`
+	}
+	if err := NewRequest(&v, "/app/token", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Token != "secret" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+	if len(client.inputs) != 0 {
+		t.Fatalf("expected no path fetch, got %d", len(client.inputs))
+	}
+
+	var missing MissingParameters
+	err := NewRequest(&v, "/app/other", client).Send(context.Background())
+	if !errors.As(err, &missing) || missing[0] != "/app/other" {
+		t.Fatalf("expected missing /app/other, got %v", err)
+	}
+}
+
+func TestSelfWithPathFields(t *testing.T) {
+	client := &recordingClient{fakeClient: fakeClient{parameters: map[string]string{
+		"/app":     "root",
+		"/app/Foo": "foo",
+	}}}
+	var v struct {
+		Root string `ssm:",self"`
+		Foo  string `ssm:"Foo"`
+	}
+	if err := NewRequest(&v, "/app", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Root != "root" || v.Foo != "foo" || len(client.inputs) != 1 {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+}
+
+func TestSelfErrors(t *testing.T) {
+	expectPanic(t, func() {
+		var v struct {
+			Token string `ssm:",self"`
+		}
+		NewRequest(&v, "/app", blockingClient{})
+	})
+	expectPanic(t, func() {
+		var v struct {
+			Token string `ssm:"Token,self"`
+		}
+		NewRequest(&v, "/app", &fakeClient{})
+	})
+}
+
+// batchingClient is a fakeClient that also implements GetParameters,
+// recording the names of each call.
+type batchingClient struct {
+	fakeClient
+	batches [][]string
+}
+
+func (c *batchingClient) GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	c.batches = append(c.batches, params.Names)
+	var output ssm.GetParametersOutput
+	for _, name := range params.Names {
+		if value, ok := c.parameters[name]; ok {
+			output.Parameters = append(output.Parameters, types.Parameter{Name: aws.String(name), Value: aws.String(value), Type: types.ParameterTypeString})
+		}
+	}
+	return &output, nil
+}
+
+func TestPathModifier(t *testing.T) {
+	parameters := map[string]string{
+		"/app/Foo":        "foo",
+		"/common/region":  "us-west-2",
+		"/common/zone":    "us-west-2a",
+		"/app/nested/Bar": "bar",
+	}
+	type config struct {
+		Foo    string `ssm:"Foo"`
+		Region string `ssm:"Region,path=/common/region"`
+		Zone   string `ssm:",path=common/zone/"`
+		Bar    string `ssm:"Bar,path=/app/nested/Bar,optional"`
+	}
+
+	client := &recordingClient{fakeClient: fakeClient{parameters: parameters}}
+	var v config
+	if err := NewRequest(&v, "/app", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v != (config{Foo: "foo", Region: "us-west-2", Zone: "us-west-2a", Bar: "bar"}) || len(client.inputs) != 1 {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	batching := &batchingClient{fakeClient: fakeClient{parameters: parameters}}
+	v = config{}
+	if err := NewRequest(&v, "/app", batching).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"/app/nested/Bar", "/common/region", "/common/zone"}}
+	if v.Region != "us-west-2" || v.Zone != "us-west-2a" || !reflect.DeepEqual(batching.batches, want) {
+		t.Fatalf("unexpected result %+v after batches %v", v, batching.batches)
+	}
+}
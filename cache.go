@@ -0,0 +1,97 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+type contextCacheKey struct{}
+
+type contextCache struct {
+	lock  sync.Mutex
+	pages map[pageKey]*ssm.GetParametersByPathOutput
+}
+
+// pageKey identifies a page by the client that fetched it, so that requests
+// to other regions or accounts don't share it, and by its input.
+type pageKey struct {
+	client         PathFetcher
+	path           string
+	recursive      bool
+	withDecryption bool
+	maxResults     int32
+	nextToken      string
+}
+
+// ContextWithCache returns a copy of ctx carrying an empty cache of fetched
+// pages. Requests built with WithContextCache and sent with the returned
+// context, or one derived from it, share the pages they fetch instead of
+// fetching them again. The cache lives exactly as long as the context.
+func ContextWithCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextCacheKey{}, &contextCache{
+		pages: make(map[pageKey]*ssm.GetParametersByPathOutput),
+	})
+}
+
+// WithContextCache makes Send reuse pages cached in its context by
+// ContextWithCache. It has no effect on contexts without a cache.
+func WithContextCache() Option {
+	return func(c *requestConfig) {
+		c.contextCache = true
+	}
+}
+
+type cachingClient struct {
+	client PathFetcher
+}
+
+func (c cachingClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	cache, _ := ctx.Value(contextCacheKey{}).(*contextCache)
+	// A client that can't be compared, and so can't key its pages, isn't
+	// cached.
+	if cache == nil || len(params.ParameterFilters) > 0 || !reflect.TypeOf(c.client).Comparable() {
+		return c.client.GetParametersByPath(ctx, params, optFns...)
+	}
+
+	key := pageKey{
+		client:         c.client,
+		path:           normalizeName(aws.ToString(params.Path)),
+		recursive:      aws.ToBool(params.Recursive),
+		withDecryption: aws.ToBool(params.WithDecryption),
+		maxResults:     aws.ToInt32(params.MaxResults),
+		nextToken:      aws.ToString(params.NextToken),
+	}
+	cache.lock.Lock()
+	page, ok := cache.pages[key]
+	cache.lock.Unlock()
+	if ok {
+		return page, nil
+	}
+
+	page, err := c.client.GetParametersByPath(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	cache.lock.Lock()
+	cache.pages[key] = page
+	cache.lock.Unlock()
+	return page, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithContextCache(t *testing.T) {
+	client := &recordingClient{fakeClient: fakeClient{parameters: map[string]string{
+		"/HasTags/Foo":         "foo",
+		"/HasTags/OptionalBar": "bar",
+	}, pageSize: 1}}
+
+	ctx := ContextWithCache(context.Background())
+	var first, second hasTags
+	if err := NewRequest(&first, "/HasTags", client, WithContextCache()).Send(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewRequest(&second, "/HasTags/", client, WithContextCache()).Send(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if first != second || second.OptionalBar != "bar" {
+		t.Fatalf("unexpected results: %+v %+v", first, second)
+	}
+	if len(client.inputs) != 2 {
+		t.Fatalf("expected the second Send to reuse both cached pages, got %d fetches", len(client.inputs))
+	}
+
+	var third hasTags
+	if err := NewRequest(&third, "/HasTags", client, WithContextCache()).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewRequest(&third, "/HasTags", client).Send(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.inputs) != 6 {
+		t.Fatalf("expected uncached fetches, got %d fetches", len(client.inputs))
+	}
+
+	other := &recordingClient{fakeClient: fakeClient{parameters: map[string]string{
+		"/HasTags/Foo":         "other",
+		"/HasTags/OptionalBar": "bar",
+	}, pageSize: 1}}
+	var fourth hasTags
+	if err := NewRequest(&fourth, "/HasTags", other, WithContextCache()).Send(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if fourth.Foo != "other" || len(other.inputs) != 2 {
+		t.Fatalf("expected another client not to share the cached pages, got %+v after %d fetches", fourth, len(other.inputs))
+	}
+}
@@ -0,0 +1,475 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Setter is implemented by field types that decode their own parameter
+// value. Errors are reported as a ParseError for the parameter.
+type Setter interface {
+	SetFromSSM(value string) error
+}
+
+type fieldTag struct {
+	suffix    string
+	optional  bool
+	modifiers map[string]string
+}
+
+// parseTag parses `suffix,modifier,key=value,...`. A backslash escapes the
+// character after it and commas inside single quotes don't separate
+// modifiers, so a default of "a,b" can be written `ssm:"Name,default=a\\,b"`
+// or `ssm:"Name,default='a,b'"`.
+func parseTag(tag string) fieldTag {
+	tagParts := splitTag(tag)
+	t := fieldTag{
+		suffix:    strings.Trim(tagParts[0], "/"),
+		modifiers: make(map[string]string, len(tagParts)-1),
+	}
+	for i, part := range tagParts[1:] {
+		key, value, _ := strings.Cut(part, "=")
+		if key == "optional" {
+			t.optional = true
+			continue
+		}
+		if key == "requiredIn" {
+			// The environments take the rest of the tag.
+			t.modifiers[key] = strings.Join(append([]string{value}, tagParts[i+2:]...), ",")
+			break
+		}
+		t.modifiers[key] = value
+	}
+	return t
+}
+
+// requiredIn settles whether a field tagged requiredIn is optional in the
+// environment given to WithEnvironment, as in
+// `ssm:"Sentry,requiredIn=prod,staging"`, which must end the tag.
+func (t *fieldTag) requiredIn(environment string) error {
+	environments, ok := t.modifiers["requiredIn"]
+	if !ok {
+		return nil
+	}
+	if t.optional {
+		return fmt.Errorf("requiredIn with optional")
+	}
+	t.optional = true
+	for _, env := range strings.Split(environments, ",") {
+		if env != "" && env == environment {
+			t.optional = false
+		}
+	}
+	return nil
+}
+
+func splitTag(tag string) []string {
+	var parts []string
+	var part strings.Builder
+	quoted := false
+	for i := 0; i < len(tag); i++ {
+		switch c := tag[i]; {
+		case c == '\\' && i+1 < len(tag):
+			i++
+			part.WriteByte(tag[i])
+		case c == '\'':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(c)
+		}
+	}
+	return append(parts, part.String())
+}
+
+func (t fieldTag) has(modifier string) bool {
+	_, ok := t.modifiers[modifier]
+	return ok
+}
+
+// newSetter returns the setter for f along with the kind of decoder it uses,
+// as reported by DescribeBindings.
+func newSetter(f reflect.Value, tag fieldTag, config *requestConfig) (func(string) error, string, error) {
+	setter, kind, err := newDecoder(f, tag, config)
+	if err != nil {
+		return nil, "", err
+	}
+	if setter, err = foldCase(setter, kind, tag, config); err != nil {
+		return nil, "", err
+	}
+
+	prefix, trimPrefix := tag.modifiers["trimPrefix"]
+	suffix, trimSuffix := tag.modifiers["trimSuffix"]
+	if !trimPrefix && !trimSuffix {
+		return setter, kind, nil
+	}
+	return func(value string) error {
+		value = strings.TrimPrefix(value, prefix)
+		value = strings.TrimSuffix(value, suffix)
+		return setter(value)
+	}, kind, nil
+}
+
+// foldCase wraps the setter of a string or []string field to fold the case
+// of its value, per the case modifier or else WithValueCaseFold.
+func foldCase(setter func(string) error, kind string, tag fieldTag, config *requestConfig) (func(string) error, error) {
+	c := config.valueCase
+	if token, ok := tag.modifiers["case"]; ok {
+		if kind != "string" && kind != "stringlist" {
+			return nil, fmt.Errorf("case modifier on non-string field")
+		}
+		switch token {
+		case "none":
+			c = CaseNone
+		case "lower":
+			c = CaseLower
+		case "upper":
+			c = CaseUpper
+		default:
+			return nil, fmt.Errorf("invalid case %q", token)
+		}
+	}
+	if kind != "string" && kind != "stringlist" {
+		return setter, nil
+	}
+	switch c {
+	case CaseLower:
+		return func(value string) error {
+			return setter(strings.ToLower(value))
+		}, nil
+	case CaseUpper:
+		return func(value string) error {
+			return setter(strings.ToUpper(value))
+		}, nil
+	}
+	return setter, nil
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+func newDecoder(f reflect.Value, tag fieldTag, config *requestConfig) (func(string) error, string, error) {
+	var modifiers []string
+	for modifier := range config.decoders {
+		if tag.has(modifier) {
+			modifiers = append(modifiers, modifier)
+		}
+	}
+	if len(modifiers) > 1 {
+		sort.Strings(modifiers)
+		return nil, "", errors.New("tagged with more than one decoder: " + modifiers[0] + " and " + modifiers[1])
+	}
+	if len(modifiers) == 1 {
+		decode := config.decoders[modifiers[0]]
+		target := f.Addr().Interface()
+		return func(value string) error {
+			return decode(value, target)
+		}, "decoder:" + modifiers[0], nil
+	}
+
+	if setter, ok := f.Addr().Interface().(Setter); ok {
+		return setter.SetFromSSM, "setter", nil
+	}
+	// Types made for the command line often implement flag.Value already.
+	if value, ok := f.Addr().Interface().(flag.Value); ok {
+		return value.Set, "flag", nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		if tag.has("base") {
+			return nil, "", fmt.Errorf("base modifier on non-integer field")
+		}
+		return func(value string) error {
+			f.SetString(value)
+			return nil
+		}, "string", nil
+	case reflect.Bool:
+		truthy, falsy, err := tag.boolValues()
+		if err != nil {
+			return nil, "", err
+		}
+		if truthy == "" {
+			return func(value string) error {
+				b, err := strconv.ParseBool(value)
+				if err != nil {
+					return err
+				}
+				f.SetBool(b)
+				return nil
+			}, "bool", nil
+		}
+		return func(value string) error {
+			switch value {
+			case truthy:
+				f.SetBool(true)
+			case falsy:
+				f.SetBool(false)
+			default:
+				return fmt.Errorf("%q is neither %q nor %q", value, truthy, falsy)
+			}
+			return nil
+		}, "bool", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if tag.has("bytes") {
+			return newByteSizeDecoder(f, tag)
+		}
+		base, err := tag.base()
+		if err != nil {
+			return nil, "", err
+		}
+		bitSize := f.Type().Bits()
+		return func(value string) error {
+			n, err := strconv.ParseInt(value, base, bitSize)
+			if err != nil {
+				return err
+			}
+			f.SetInt(n)
+			return nil
+		}, "int", nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if tag.has("bytes") {
+			return newByteSizeDecoder(f, tag)
+		}
+		base, err := tag.base()
+		if err != nil {
+			return nil, "", err
+		}
+		bitSize := f.Type().Bits()
+		return func(value string) error {
+			n, err := strconv.ParseUint(value, base, bitSize)
+			if err != nil {
+				return err
+			}
+			f.SetUint(n)
+			return nil
+		}, "uint", nil
+	case reflect.Slice:
+		if f.Type() == rawMessageType && config.validateRawJSON {
+			return func(value string) error {
+				if !json.Valid([]byte(value)) {
+					return fmt.Errorf("invalid JSON")
+				}
+				f.SetBytes([]byte(value))
+				return nil
+			}, "byteslice", nil
+		}
+		if f.Type().Elem().Kind() == reflect.Uint8 {
+			return func(value string) error {
+				f.SetBytes([]byte(value))
+				return nil
+			}, "byteslice", nil
+		}
+		if f.Type().Elem().Kind() == reflect.String {
+			return func(value string) error {
+				// Size the slice from the commas and fill it in place, so
+				// that a long list isn't split into a copy first.
+				n := 0
+				if value != "" {
+					n = strings.Count(value, ",") + 1
+				}
+				if limit := config.maxListElements; limit > 0 && n > limit {
+					return fmt.Errorf("list of %d elements exceeds the limit of %d", n, limit)
+				}
+				sv := reflect.MakeSlice(f.Type(), n, n)
+				for i := 0; i < n; i++ {
+					item, rest, _ := strings.Cut(value, ",")
+					sv.Index(i).SetString(item)
+					value = rest
+				}
+				f.Set(sv)
+				return nil
+			}, "stringlist", nil
+		}
+	case reflect.Map:
+		if tag.has("kv") && f.Type().Key().Kind() == reflect.String && f.Type().Elem().Kind() == reflect.String {
+			return func(value string) error {
+				m, err := parseKeyValuePairs(value)
+				if err != nil {
+					return err
+				}
+				mv := reflect.MakeMapWithSize(f.Type(), len(m))
+				for k, v := range m {
+					mv.SetMapIndex(reflect.ValueOf(k).Convert(f.Type().Key()), reflect.ValueOf(v).Convert(f.Type().Elem()))
+				}
+				f.Set(mv)
+				return nil
+			}, "kv", nil
+		}
+	case reflect.Func:
+		// The func the field holds at NewRequest is called with the value
+		// in place of an assignment, for side effects no type can express.
+		switch fn := f.Interface().(type) {
+		case func(string):
+			if fn == nil {
+				return nil, "", fmt.Errorf("nil func field")
+			}
+			return func(value string) error {
+				fn(value)
+				return nil
+			}, "func", nil
+		case func(string) error:
+			if fn == nil {
+				return nil, "", fmt.Errorf("nil func field")
+			}
+			return fn, "func", nil
+		}
+	}
+	return nil, "", fmt.Errorf("unsupported type %s", f.Type())
+}
+
+// splitList splits a StringList value into its items.
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
+
+func newListMemberSetter(f reflect.Value, member string) (func(string) error, error) {
+	if f.Kind() != reflect.Bool {
+		return nil, fmt.Errorf("inlist modifier on non-bool field")
+	}
+	return func(value string) error {
+		found := false
+		for _, item := range splitList(value) {
+			if item == member {
+				found = true
+				break
+			}
+		}
+		f.SetBool(found)
+		return nil
+	}, nil
+}
+
+func parseKeyValuePairs(value string) (map[string]string, error) {
+	m := make(map[string]string)
+	if value == "" {
+		return m, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("malformed key=value pair %q", pair)
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+func (t fieldTag) base() (int, error) {
+	token, ok := t.modifiers["base"]
+	if !ok {
+		return 10, nil
+	}
+	base, err := strconv.Atoi(token)
+	if err != nil || base == 1 || base < 0 || base > 36 {
+		return 0, fmt.Errorf("invalid base %q", token)
+	}
+	return base, nil
+}
+
+// boolValues returns the strings given by the truthy and falsy modifiers,
+// which must be given together and differ, or empty strings if neither is.
+func (t fieldTag) boolValues() (truthy, falsy string, err error) {
+	truthy, hasTruthy := t.modifiers["truthy"]
+	falsy, hasFalsy := t.modifiers["falsy"]
+	switch {
+	case !hasTruthy && !hasFalsy:
+		return "", "", nil
+	case !hasTruthy || !hasFalsy:
+		return "", "", fmt.Errorf("truthy and falsy modifiers must be given together")
+	case truthy == "" || truthy == falsy:
+		return "", "", fmt.Errorf("truthy %q and falsy %q must be distinct and non-empty", truthy, falsy)
+	}
+	return truthy, falsy, nil
+}
+
+// byteSizeSuffixes are the suffixes of Kubernetes resource quantities, SI
+// and binary, that apply to sizes in bytes.
+var byteSizeSuffixes = map[string]int64{
+	"":   1,
+	"k":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"E":  1e18,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+	"Ei": 1 << 60,
+}
+
+func newByteSizeDecoder(f reflect.Value, tag fieldTag) (func(string) error, string, error) {
+	if tag.has("base") {
+		return nil, "", fmt.Errorf("base modifier on bytes field")
+	}
+	return func(value string) error {
+		n, err := parseByteSize(value)
+		if err != nil {
+			return err
+		}
+		if f.CanInt() {
+			if f.OverflowInt(n) {
+				return fmt.Errorf("byte size %q out of range for %s", value, f.Type())
+			}
+			f.SetInt(n)
+		} else {
+			if f.OverflowUint(uint64(n)) {
+				return fmt.Errorf("byte size %q out of range for %s", value, f.Type())
+			}
+			f.SetUint(uint64(n))
+		}
+		return nil
+	}, "bytes", nil
+}
+
+// parseByteSize parses a number of bytes such as "512Mi", "1.5G" or "100",
+// which must come to a whole number of bytes.
+func parseByteSize(value string) (int64, error) {
+	number, suffix := value, ""
+	if i := strings.IndexFunc(value, func(r rune) bool { return (r < '0' || r > '9') && r != '.' }); i >= 0 {
+		number, suffix = value[:i], value[i:]
+	}
+	multiplier, ok := byteSizeSuffixes[suffix]
+	whole, fraction, _ := strings.Cut(number, ".")
+	digits, valid := new(big.Int).SetString(whole+fraction, 10)
+	if !ok || !valid || whole == "" {
+		return 0, fmt.Errorf("invalid byte size %q", value)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(len(fraction))), nil)
+	n, remainder := new(big.Int).QuoRem(digits.Mul(digits, big.NewInt(multiplier)), scale, new(big.Int))
+	switch {
+	case remainder.Sign() != 0:
+		return 0, fmt.Errorf("byte size %q is not a whole number of bytes", value)
+	case !n.IsInt64():
+		return 0, fmt.Errorf("byte size %q out of range", value)
+	}
+	return n.Int64(), nil
+}
@@ -0,0 +1,761 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestIntegerBase(t *testing.T) {
+	var v struct {
+		Umask  uint32 `ssm:"Umask,base=8"`
+		Mask   int64  `ssm:"Mask,base=16"`
+		Count  int    `ssm:"Count"`
+		Prefix int    `ssm:"Prefix,optional,base=0"`
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/Umask":  "022",
+		"/app/Mask":   "ff",
+		"/app/Count":  "42",
+		"/app/Prefix": "0x10",
+	}}
+	if err := NewRequest(&v, "/app", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Umask != 0o22 || v.Mask != 0xff || v.Count != 42 || v.Prefix != 16 {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+}
+
+func TestIntegerParseError(t *testing.T) {
+	var v struct {
+		Count int `ssm:"Count,base=8"`
+	}
+	client := &fakeClient{parameters: map[string]string{"/app/Count": "9"}}
+	err := NewRequest(&v, "/app", client).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/app/Count" {
+		t.Fatalf("expected parse error for /app/Count, got %v", err)
+	}
+}
+
+func expectPanic(t *testing.T, f func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	f()
+}
+
+func TestIntegerInvalidBase(t *testing.T) {
+	client := &fakeClient{}
+	expectPanic(t, func() {
+		var v struct {
+			Count int `ssm:"Count,base=x"`
+		}
+		NewRequest(&v, "/app", client)
+	})
+	expectPanic(t, func() {
+		var v struct {
+			Count int `ssm:"Count,base=37"`
+		}
+		NewRequest(&v, "/app", client)
+	})
+	expectPanic(t, func() {
+		var v struct {
+			Name string `ssm:"Name,base=8"`
+		}
+		NewRequest(&v, "/app", client)
+	})
+}
+
+func TestKeyValueMap(t *testing.T) {
+	var v struct {
+		Opts  map[string]string `ssm:"Opts,kv"`
+		Empty map[string]string `ssm:"Empty,kv"`
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/Opts":  "a=1,b=2,c=",
+		"/app/Empty": "",
+	}}
+	if err := NewRequest(&v, "/app", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v.Opts, map[string]string{"a": "1", "b": "2", "c": ""}) || v.Empty == nil || len(v.Empty) != 0 {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	client.parameters["/app/Opts"] = "a=1,b"
+	err := NewRequest(&v, "/app", client).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/app/Opts" {
+		t.Fatalf("expected parse error for /app/Opts, got %v", err)
+	}
+}
+
+func TestMapWithoutModifier(t *testing.T) {
+	expectPanic(t, func() {
+		var v struct {
+			Opts map[string]string `ssm:"Opts"`
+		}
+		NewRequest(&v, "/app", &fakeClient{})
+	})
+}
+
+func TestWithDecoder(t *testing.T) {
+	type point struct{ X, Y int }
+	var v struct {
+		Origin point `ssm:"Origin,point"`
+	}
+	decodePoint := func(value string, target interface{}) error {
+		x, y, ok := strings.Cut(value, ",")
+		if !ok {
+			return errors.New("expected x,y")
+		}
+		p := target.(*point)
+		var err error
+		if p.X, err = strconv.Atoi(x); err != nil {
+			return err
+		}
+		p.Y, err = strconv.Atoi(y)
+		return err
+	}
+	client := &fakeClient{parameters: map[string]string{"/app/Origin": "3,4"}}
+	if err := NewRequest(&v, "/app", client, WithDecoder("point", decodePoint)).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Origin != (point{3, 4}) {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	var both struct {
+		Origin point `ssm:"Origin,point,pair"`
+	}
+	_, err := TryNewRequest(&both, "/app", client, WithDecoder("point", decodePoint), WithDecoder("pair", decodePoint))
+	if err == nil || !strings.Contains(err.Error(), "more than one decoder: pair and point") {
+		t.Fatalf("expected an error for two decoders, got %v", err)
+	}
+}
+
+type hostPort struct {
+	Host string
+	Port int
+}
+
+func (h *hostPort) SetFromSSM(value string) error {
+	host, port, ok := strings.Cut(value, ":")
+	if !ok {
+		return errors.New("expected host:port")
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return err
+	}
+	h.Host, h.Port = host, n
+	return nil
+}
+
+func TestSetter(t *testing.T) {
+	var v struct {
+		DB hostPort `ssm:"DB"`
+	}
+	client := &fakeClient{parameters: map[string]string{"/app/DB": "db.internal:5432"}}
+	if err := NewRequest(&v, "/app", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.DB != (hostPort{"db.internal", 5432}) {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	client.parameters["/app/DB"] = "db.internal"
+	err := NewRequest(&v, "/app", client).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/app/DB" {
+		t.Fatalf("expected parse error for /app/DB, got %v", err)
+	}
+}
+
+func TestTrimPrefixSuffix(t *testing.T) {
+	var v struct {
+		Role   string `ssm:"RoleArn,trimPrefix=arn:aws:iam::"`
+		Bucket string `ssm:"Bucket,trimPrefix=s3://,trimSuffix=/"`
+		Port   int    `ssm:"Port,trimPrefix=:"`
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/RoleArn": "arn:aws:iam::123456789012:role/app",
+		"/app/Bucket":  "s3://bucket/",
+		"/app/Port":    ":8080",
+	}}
+	if err := NewRequest(&v, "/app", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Role != "123456789012:role/app" || v.Bucket != "bucket" || v.Port != 8080 {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+}
+
+func TestParseTagEscapes(t *testing.T) {
+	for tag, expected := range map[string]string{
+		`List,default=1\,2\,3`:       "1,2,3",
+		`List,default='1,2,3'`:       "1,2,3",
+		`List,default='a,b'\,c,kv`:   "a,b,c",
+		`List,default=it\'s`:         "it's",
+		`List,kv,default=trailing\\`: `trailing\`,
+	} {
+		parsed := parseTag(tag)
+		if parsed.modifiers["default"] != expected {
+			t.Errorf("%s: expected default %q, got %q", tag, expected, parsed.modifiers["default"])
+		}
+	}
+	if parsed := parseTag(`List,default='1,2',kv`); !parsed.has("kv") || parsed.suffix != "List" {
+		t.Errorf("unexpected modifiers: %+v", parsed)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	var v struct {
+		List    string            `ssm:"List,default=1\\,2\\,3"`
+		Quoted  string            `ssm:"Quoted,default='a,b'"`
+		Count   int               `ssm:"Count,default=7"`
+		Opts    map[string]string `ssm:"Opts,kv,default='x=1,y=2'"`
+		Present string            `ssm:"Present,default=unused"`
+	}
+	client := &fakeClient{parameters: map[string]string{"/app/Present": "present"}}
+	req := NewRequest(&v, "/app", client)
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.List != "1,2,3" || v.Quoted != "a,b" || v.Count != 7 || v.Opts["y"] != "2" || v.Present != "present" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+	if resolved := req.Resolved(); !reflect.DeepEqual(resolved, []string{"/app/Present"}) {
+		t.Fatalf("defaults shouldn't count as resolved: %v", resolved)
+	}
+
+	var invalid struct {
+		Count int `ssm:"Count,default=seven"`
+	}
+	err := NewRequest(&invalid, "/app", client).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/app/Count" {
+		t.Fatalf("expected parse error for /app/Count, got %v", err)
+	}
+}
+
+func TestDefaultFrom(t *testing.T) {
+	type service struct {
+		Timeout int    `ssm:"Timeout,optional,defaultFrom=/app/global/timeout"`
+		Region  string `ssm:"Region,optional,defaultFrom=/app/svc/DefaultRegion"`
+		Owner   string `ssm:"Owner,optional,defaultFrom=/app/global/owner"`
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/global/timeout":    "30",
+		"/app/svc/DefaultRegion": "us-east-1",
+	}}
+	var v service
+	if err := NewRequest(&v, "/app/svc", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Timeout != 30 || v.Region != "us-east-1" || v.Owner != "" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	client.parameters["/app/svc/Timeout"] = "5"
+	v = service{}
+	if err := NewRequest(&v, "/app/svc", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Timeout != 5 {
+		t.Fatalf("expected the field's own parameter to win, got %+v", v)
+	}
+
+	var required struct {
+		Timeout int `ssm:"Timeout,defaultFrom=/app/global/timeout"`
+	}
+	if _, err := TryNewRequest(&required, "/app/svc", client); err == nil {
+		t.Fatal("expected error for defaultFrom without optional")
+	}
+}
+
+func TestBool(t *testing.T) {
+	type modes struct {
+		Debug bool `ssm:"Debug"`
+		Mode  bool `ssm:"Mode,truthy=enabled,falsy=disabled"`
+	}
+	client := &fakeClient{parameters: map[string]string{"/app/Debug": "true", "/app/Mode": "enabled"}}
+	var v modes
+	if err := NewRequest(&v, "/app", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Debug || !v.Mode {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	for _, mode := range []string{"true", "Enabled", ""} {
+		client.parameters["/app/Mode"] = mode
+		err := NewRequest(&v, "/app", client).Send(context.Background())
+		var parseErrors ParseErrors
+		if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/app/Mode" {
+			t.Errorf("expected parse error for %q, got %v", mode, err)
+		}
+	}
+
+	for _, tag := range []string{`ssm:"Mode,truthy=on"`, `ssm:"Mode,truthy=on,falsy=on"`, `ssm:"Mode,truthy=,falsy=off"`} {
+		invalid := reflect.New(reflect.StructOf([]reflect.StructField{
+			{Name: "Mode", Type: reflect.TypeOf(false), Tag: reflect.StructTag(tag)},
+		}))
+		if _, err := TryNewRequest(invalid.Interface(), "/app", client); err == nil {
+			t.Errorf("expected error for %s", tag)
+		}
+	}
+}
+
+// orderedSetter records the order in which setters sharing a name run.
+type orderedSetter struct {
+	label string
+	log   *[]string
+}
+
+func (s *orderedSetter) SetFromSSM(value string) error {
+	*s.log = append(*s.log, s.label+"="+value)
+	return nil
+}
+
+func TestSetterOrder(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{"/app/shared": "v:1"}}
+	for i := 0; i < 20; i++ {
+		var log []string
+		var v struct {
+			A       orderedSetter `ssm:"shared"`
+			Trimmed string        `ssm:"shared,trimPrefix=v:"`
+			B       orderedSetter `ssm:"shared"`
+			C       orderedSetter `ssm:"shared"`
+			D       orderedSetter `ssm:"shared"`
+		}
+		v.A = orderedSetter{label: "A", log: &log}
+		v.B = orderedSetter{label: "B", log: &log}
+		v.C = orderedSetter{label: "C", log: &log}
+		v.D = orderedSetter{label: "D", log: &log}
+		req := NewRequest(&v, "/app", client)
+		req.AddParameter("/app/shared", func(value string) {
+			log = append(log, "extra="+value)
+		})
+		if err := req.Send(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if expected := []string{"A=v:1", "B=v:1", "C=v:1", "D=v:1", "extra=v:1"}; !reflect.DeepEqual(log, expected) || v.Trimmed != "1" {
+			t.Fatalf("expected setters in declaration order, got %v (%q)", log, v.Trimmed)
+		}
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	for value, expected := range map[string]int64{
+		"100":   100,
+		"512Mi": 512 << 20,
+		"2Gi":   2 << 30,
+		"1.5G":  1500000000,
+		"0.5Ki": 512,
+		"8k":    8000,
+		"7Ei":   7 << 60,
+	} {
+		if n, err := parseByteSize(value); err != nil || n != expected {
+			t.Errorf("%s: expected %d, got %d, %v", value, expected, n, err)
+		}
+	}
+	for _, value := range []string{"", "Mi", "1.5", "512MB", "-1Gi", "1.2.3G", ".5Gi", "8Ei", "0.1"} {
+		if n, err := parseByteSize(value); err == nil {
+			t.Errorf("%s: expected error, got %d", value, n)
+		}
+	}
+
+	var v struct {
+		Limit  int64  `ssm:"Limit,bytes"`
+		Buffer uint16 `ssm:"Buffer,bytes"`
+	}
+	client := &fakeClient{parameters: map[string]string{"/app/Limit": "512Mi", "/app/Buffer": "64Ki"}}
+	err := NewRequest(&v, "/app", client).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 1 || parseErrors[0].Name != "/app/Buffer" || v.Limit != 512<<20 {
+		t.Fatalf("expected /app/Buffer to overflow, got %v (%+v)", err, v)
+	}
+}
+
+func TestByteSlice(t *testing.T) {
+	const pem = "-----BEGIN CERTIFICATE-----\r\nMIIBszCCAVmgAwIBAgIU+/=\n\"quoted\" \\ tab\there ünïcode\n-----END CERTIFICATE-----\n"
+	type tls struct {
+		Cert []byte `ssm:"Cert,secure"`
+	}
+	put := &putClient{}
+	if err := WriteConfig(context.Background(), &tls{Cert: []byte(pem)}, "/app", put); err != nil {
+		t.Fatal(err)
+	}
+	written := put.written()["/app/Cert"]
+	if aws.ToString(written.Value) != pem || written.Type != types.ParameterTypeSecureString {
+		t.Fatalf("unexpected write: %+v", written)
+	}
+
+	client := &fakeClient{
+		parameters: map[string]string{"/app/Cert": aws.ToString(written.Value)},
+		secure:     map[string]bool{"/app/Cert": true},
+	}
+	var v tls
+	req := NewRequest(&v, "/app", client)
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if string(v.Cert) != pem {
+		t.Fatalf("unexpected round trip: %q", v.Cert)
+	}
+	if kind := req.DescribeBindings()["Cert"]; kind != "byteslice" {
+		t.Fatalf("unexpected binding %q", kind)
+	}
+}
+
+func TestDescribeBindings(t *testing.T) {
+	var v struct {
+		Name    string            `ssm:"Name"`
+		Count   int               `ssm:"Count"`
+		Umask   uint32            `ssm:"Umask,base=8"`
+		Opts    map[string]string `ssm:"Opts,kv"`
+		DB      hostPort          `ssm:"DB"`
+		Origin  hostPort          `ssm:"Origin,point"`
+		Storage storage           `ssm:"storage"`
+		Ignored string
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/storage/type":   "s3",
+		"/app/storage/bucket": "bucket",
+	}}
+	req := NewRequest(&v, "/app", client,
+		WithDecoder("point", func(string, interface{}) error { return nil }),
+		WithInterfaceFactory("Storage", storageFactory),
+	)
+	expected := map[string]string{
+		"Name":    "string",
+		"Count":   "int",
+		"Umask":   "uint",
+		"Opts":    "kv",
+		"DB":      "setter",
+		"Origin":  "decoder:point",
+		"Storage": "interface",
+	}
+	if bindings := req.DescribeBindings(); !reflect.DeepEqual(bindings, expected) {
+		t.Fatalf("unexpected bindings: %v", bindings)
+	}
+
+	_ = req.Send(context.Background())
+	expected["Storage.Bucket"] = "string"
+	expected["Storage.Prefix"] = "string"
+	if bindings := req.DescribeBindings(); !reflect.DeepEqual(bindings, expected) {
+		t.Fatalf("unexpected bindings after Send: %v", bindings)
+	}
+
+	if bindings := NewRequest(&hasTags{}, "/app", client).DescribeBindings(); !reflect.DeepEqual(bindings, map[string]string{"Foo": "string", "OptionalBar": "string"}) {
+		t.Fatalf("unexpected bindings: %v", bindings)
+	}
+}
+
+func TestStringList(t *testing.T) {
+	var v struct {
+		Zones []string `ssm:"Zones"`
+		Empty []string `ssm:"Empty"`
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/Zones": "us-west-2a,us-west-2b",
+		"/app/Empty": "",
+	}}
+	if err := NewRequest(&v, "/app", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v.Zones, []string{"us-west-2a", "us-west-2b"}) || v.Empty == nil || len(v.Empty) != 0 {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+}
+
+func TestWithMaxListElements(t *testing.T) {
+	var v struct {
+		Zones []string `ssm:"Zones"`
+	}
+	client := &fakeClient{parameters: map[string]string{"/app/Zones": "a,b,c"}}
+	if err := NewRequest(&v, "/app", client, WithMaxListElements(3)).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	v.Zones = nil
+	err := NewRequest(&v, "/app", client, WithMaxListElements(2)).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || v.Zones != nil {
+		t.Fatalf("expected a ParseError and no slice, got %v and %v", err, v.Zones)
+	}
+}
+
+func TestWithValueCaseFold(t *testing.T) {
+	var v struct {
+		Region  string   `ssm:"Region"`
+		Zones   []string `ssm:"Zones"`
+		Token   string   `ssm:"Token,case=none"`
+		Kind    string   `ssm:"Kind,case=upper,trimPrefix=kind:"`
+		Encoded string   `ssm:"Encoded,raw"`
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/Region":  "US-West-2",
+		"/app/Zones":   "US-West-2a,US-West-2b",
+		"/app/Token":   "AbC",
+		"/app/Kind":    "kind:Primary",
+		"/app/Encoded": "SGVsbG8=",
+	}}
+	raw := WithDecoder("raw", func(value string, target interface{}) error {
+		*target.(*string) = value
+		return nil
+	})
+	if err := NewRequest(&v, "/app", client, WithValueCaseFold(CaseLower), raw).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Region != "us-west-2" || !reflect.DeepEqual(v.Zones, []string{"us-west-2a", "us-west-2b"}) || v.Token != "AbC" || v.Kind != "PRIMARY" || v.Encoded != "SGVsbG8=" {
+		t.Errorf("unexpected result: %+v", v)
+	}
+
+	var invalid struct {
+		Port int `ssm:"Port,case=lower"`
+	}
+	if _, err := TryNewRequest(&invalid, "/app", client); err == nil {
+		t.Error("expected an error for case on an int field")
+	}
+}
+
+func TestRequiredIn(t *testing.T) {
+	type config struct {
+		Sentry string `ssm:"Sentry,secure,requiredIn=prod,staging"`
+		Region string `ssm:"Region"`
+	}
+	client := &fakeClient{parameters: map[string]string{"/app/Region": "us-west-2"}}
+	for _, env := range []string{"", "dev"} {
+		var v config
+		if err := NewRequest(&v, "/app", client, WithEnvironment(env)).Send(context.Background()); err != nil {
+			t.Errorf("in %q: %v", env, err)
+		}
+	}
+	for _, env := range []string{"prod", "staging"} {
+		var v config
+		err := NewRequest(&v, "/app", client, WithEnvironment(env)).Send(context.Background())
+		var missing MissingParameters
+		if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/app/Sentry"}) {
+			t.Errorf("in %q: expected /app/Sentry missing, got %v", env, err)
+		}
+	}
+
+	if tag := parseTag("Sentry,secure,requiredIn=prod,staging"); !tag.has("secure") || tag.modifiers["requiredIn"] != "prod,staging" {
+		t.Errorf("unexpected tag: %+v", tag)
+	}
+	var invalid struct {
+		Sentry string `ssm:"Sentry,optional,requiredIn=prod"`
+	}
+	if _, err := TryNewRequest(&invalid, "/app", client); err == nil {
+		t.Error("expected an error for requiredIn with optional")
+	}
+}
+
+func BenchmarkStringList(b *testing.B) {
+	value := strings.Repeat("us-west-2a,", 9999) + "us-west-2a"
+	var v struct {
+		Zones []string `ssm:"Zones"`
+	}
+	setter, _, err := newDecoder(reflect.ValueOf(&v).Elem().Field(0), parseTag("Zones"), &requestConfig{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := setter(value); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestInList(t *testing.T) {
+	var v struct {
+		Beta    bool   `ssm:"beta,inlist=/app/enabledFeatures"`
+		Dark    bool   `ssm:"dark,inlist=app/enabledFeatures/"`
+		Legacy  bool   `ssm:"legacy,inlist=/shared/features,optional"`
+		Service string `ssm:"Service"`
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/enabledFeatures": "alpha,beta",
+		"/app/svc/Service":     "svc",
+	}}
+	req := NewRequest(&v, "/app/svc", client)
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Beta || v.Dark || v.Legacy || v.Service != "svc" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+	if names := req.ParameterNames(); !reflect.DeepEqual(names, []string{"/app/enabledFeatures", "/app/svc/Service", "/shared/features"}) {
+		t.Fatalf("unexpected parameter names: %v", names)
+	}
+
+	expectPanic(t, func() {
+		var v struct {
+			Beta string `ssm:"beta,inlist=/app/enabledFeatures"`
+		}
+		NewRequest(&v, "/app", client)
+	})
+}
+
+func TestWithJSONTagFallback(t *testing.T) {
+	type service struct {
+		Host    string `json:"host"`
+		Port    int    `json:"port,omitempty"`
+		Region  string `json:"region" ssm:"awsRegion"`
+		Name    string `json:",omitempty"`
+		Skipped string `json:"-"`
+		Neither string
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/svc/host":      "example.com",
+		"/svc/port":      "443",
+		"/svc/awsRegion": "us-west-2",
+		"/svc/region":    "wrong",
+		"/svc/Name":      "svc",
+		"/svc/Skipped":   "wrong",
+		"/svc/Neither":   "wrong",
+	}}
+	var v service
+	req := NewRequest(&v, "/svc", client, WithJSONTagFallback())
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expected := service{Host: "example.com", Port: 443, Region: "us-west-2", Name: "svc"}
+	if v != expected {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	v = service{}
+	if err := NewRequest(&v, "/svc", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v != (service{Region: "us-west-2"}) {
+		t.Fatalf("expected json tags to be ignored by default: %+v", v)
+	}
+}
+
+// level implements flag.Value, accepting only known levels.
+type level string
+
+func (l *level) String() string { return string(*l) }
+
+func (l *level) Set(value string) error {
+	switch value {
+	case "debug", "info":
+		*l = level(value)
+		return nil
+	}
+	return fmt.Errorf("unknown level %q", value)
+}
+
+func TestFlagValue(t *testing.T) {
+	var v struct {
+		Level level         `ssm:"Level"`
+		Hosts hostsFlag     `ssm:"Hosts"`
+		Other level         `ssm:"Other,optional"`
+		Dur   durationValue `ssm:"Dur"`
+	}
+	client := &fakeClient{parameters: map[string]string{"/app/Level": "debug", "/app/Hosts": "a,b", "/app/Other": "loud", "/app/Dur": "1m"}}
+	err := NewRequest(&v, "/app", client).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 1 || parseErrors[0].Name != "/app/Other" {
+		t.Fatalf("expected a ParseError for /app/Other, got %v", err)
+	}
+	if v.Level != "debug" || !reflect.DeepEqual([]string(v.Hosts), []string{"a", "b"}) || v.Dur.String() != "1m0s" {
+		t.Errorf("unexpected result: %+v", v)
+	}
+	if kind := NewRequest(&v, "/app", client).DescribeBindings()["Level"]; kind != "flag" {
+		t.Errorf("got kind %q, want flag", kind)
+	}
+	if plan := stringPlan(reflect.TypeOf(struct {
+		Level level `ssm:"Level"`
+	}{})); plan != nil {
+		t.Error("a string type implementing flag.Value took the fast path")
+	}
+}
+
+type hostsFlag []string
+
+func (h *hostsFlag) String() string { return strings.Join(*h, ",") }
+
+func (h *hostsFlag) Set(value string) error {
+	*h = strings.Split(value, ",")
+	return nil
+}
+
+type durationValue struct{ time.Duration }
+
+func (d *durationValue) Set(value string) (err error) {
+	d.Duration, err = time.ParseDuration(value)
+	return err
+}
+
+func TestFuncField(t *testing.T) {
+	var got []string
+	type handlers struct {
+		OnThing func(string)       `ssm:"OnThing"`
+		OnOther func(string) error `ssm:"OnOther,optional"`
+	}
+	v := handlers{
+		OnThing: func(value string) { got = append(got, "thing="+value) },
+		OnOther: func(value string) error { return fmt.Errorf("rejected %s", value) },
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/OnThing": "a",
+		"/app/OnOther": "b",
+	}}
+	req := NewRequest(&v, "/app", client)
+	err := req.Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 1 || parseErrors[0].Name != "/app/OnOther" {
+		t.Fatalf("expected a ParseError for /app/OnOther, got %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"thing=a"}) {
+		t.Errorf("unexpected calls: %q", got)
+	}
+	if kind := req.DescribeBindings()["OnThing"]; kind != "func" {
+		t.Errorf("got kind %q, want func", kind)
+	}
+
+	expectPanic(t, func() {
+		var v struct {
+			OnThing func(string) `ssm:"OnThing"`
+		}
+		NewRequest(&v, "/app", client)
+	})
+	expectPanic(t, func() {
+		var v struct {
+			OnThing func(int) `ssm:"OnThing"`
+		}
+		NewRequest(&v, "/app", client)
+	})
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// fetchSplit fetches by path twice: without decryption for the fields not
+// tagged secure, then with decryption for those that are. A role that can't
+// decrypt every SecureString under the path still gets the plaintext fields
+// applied before the decrypting fetch fails.
+func (r *request) fetchSplit(ctx context.Context, unmatched map[string]string, parseErrors ParseErrors) (ParseErrors, error) {
+	plain := r.input
+	plain.WithDecryption = aws.Bool(false)
+	parseErrors, err := r.fetchPath(ctx, plain, aws.Bool(false), unmatched, parseErrors)
+	if err != nil || len(r.secure) == 0 {
+		return parseErrors, err
+	}
+	if r.config.isolateSecure {
+		return r.fetchIsolated(ctx, parseErrors)
+	}
+	return r.fetchPath(ctx, r.input, aws.Bool(true), nil, parseErrors)
+}
+
+// fetchIsolated fetches the secure fields the fetch by path would return
+// with concurrent GetParameter calls, for WithIsolateSecureFetches. It
+// applies the parameters fetched before returning the first error.
+func (r *request) fetchIsolated(ctx context.Context, parseErrors ParseErrors) (ParseErrors, error) {
+	client := r.client.(GetParameterAPIClient)
+	path := normalizeName(aws.ToString(r.input.Path))
+	var names []string
+	for _, name := range sortedNames(r.secure) {
+		_, byName := r.byName[name]
+		_, external := r.external[name]
+		if !byName && !external && underPath(path, name, aws.ToBool(r.input.Recursive)) {
+			names = append(names, name)
+		}
+	}
+
+	outputs := make([]*ssm.GetParameterOutput, len(names))
+	errs := make([]error, len(names))
+	durations := make([]time.Duration, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			ctx := ctx
+			if r.config.secureTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, r.config.secureTimeout)
+				defer cancel()
+			}
+			start := time.Now()
+			outputs[i], errs[i] = client.GetParameter(ctx, &ssm.GetParameterInput{
+				Name:           aws.String(name),
+				WithDecryption: aws.Bool(true),
+			})
+			durations[i] = time.Since(start)
+		}(i, name)
+	}
+	wg.Wait()
+
+	var parameters []types.Parameter
+	var firstErr error
+	for i, name := range names {
+		if r.config.timing {
+			if r.timings.Secure == nil {
+				r.timings.Secure = make(map[string]time.Duration, len(names))
+			}
+			r.timings.Secure[name] = durations[i]
+		}
+		var notFound *types.ParameterNotFound
+		switch {
+		case errors.As(errs[i], &notFound):
+		case errs[i] != nil:
+			if firstErr == nil {
+				firstErr = fmt.Errorf("fetching %s: %w", name, errs[i])
+			}
+		default:
+			parameter := *outputs[i].Parameter
+			parameter.Name = aws.String(name)
+			parameters = append(parameters, parameter)
+		}
+	}
+	parseErrors, err := r.applyOrDefer(parameters, aws.Bool(true), nil, parseErrors)
+	if err != nil {
+		return parseErrors, err
+	}
+	return parseErrors, firstErr
+}
+
+// withDecryption reports whether to decrypt the parameter name when it's
+// fetched on its own.
+func (r *request) withDecryption(name string) *bool {
+	if !r.config.splitDecryption {
+		return r.input.WithDecryption
+	}
+	_, secure := r.secure[name]
+	return aws.Bool(secure && aws.ToBool(r.input.WithDecryption))
+}
+
+// checkSecure returns an error for a field tagged secure whose parameter is
+// not a SecureString, given WithStrictSecure.
+func (r *request) checkSecure(name string, parameterType types.ParameterType) error {
+	if _, ok := r.secure[name]; !ok || !r.config.strictSecure || parameterType == types.ParameterTypeSecureString {
+		return nil
+	}
+	return fmt.Errorf("field tagged secure has a %s parameter, not a SecureString", parameterType)
+}
@@ -0,0 +1,230 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// decryptingClient is a fakeClient that returns secure values only when
+// asked to decrypt, failing instead if it can't.
+type decryptingClient struct {
+	fakeClient
+	canDecrypt  bool
+	decryptions []bool
+}
+
+var errKMSAccessDenied = errors.New("kms access denied")
+
+func (c *decryptingClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	decrypt := aws.ToBool(params.WithDecryption)
+	c.decryptions = append(c.decryptions, decrypt)
+	if decrypt && !c.canDecrypt {
+		return nil, errKMSAccessDenied
+	}
+	output, err := c.fakeClient.GetParametersByPath(ctx, params, optFns...)
+	if err == nil && !decrypt {
+		for i, p := range output.Parameters {
+			if c.secure[*p.Name] {
+				output.Parameters[i].Value = aws.String("ciphertext")
+			}
+		}
+	}
+	return output, err
+}
+
+func TestWithSplitDecryption(t *testing.T) {
+	type service struct {
+		Host     string `ssm:"Host"`
+		Password string `ssm:"Password,secure"`
+	}
+	newClient := func(canDecrypt bool) *decryptingClient {
+		return &decryptingClient{
+			fakeClient: fakeClient{
+				parameters: map[string]string{"/svc/Host": "db.internal", "/svc/Password": "hunter2"},
+				secure:     map[string]bool{"/svc/Password": true},
+			},
+			canDecrypt: canDecrypt,
+		}
+	}
+
+	client := newClient(true)
+	var v service
+	if err := NewRequest(&v, "/svc", client, WithSplitDecryption()).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Host != "db.internal" || v.Password != "hunter2" || !reflect.DeepEqual(client.decryptions, []bool{false, true}) {
+		t.Fatalf("unexpected result: %+v with decryption %v", v, client.decryptions)
+	}
+
+	v = service{}
+	err := NewRequest(&v, "/svc", newClient(false), WithSplitDecryption()).Send(context.Background())
+	if !errors.Is(err, errKMSAccessDenied) || v.Host != "db.internal" || v.Password != "" {
+		t.Fatalf("expected plaintext fields despite %v: %+v", err, v)
+	}
+
+	var untagged struct {
+		Password string `ssm:"Password"`
+	}
+	err = NewRequest(&untagged, "/svc", newClient(true), WithSplitDecryption()).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/svc/Password" || untagged.Password != "" {
+		t.Fatalf("expected parse error for untagged SecureString, got %v (%+v)", err, untagged)
+	}
+
+	var subtree struct {
+		Keys map[string][]byte `ssm:"keys,optional"`
+	}
+	client = &decryptingClient{
+		fakeClient: fakeClient{
+			parameters: map[string]string{"/svc/keys/a": "secret", "/svc/keys/b": "plain"},
+			secure:     map[string]bool{"/svc/keys/a": true},
+		},
+		canDecrypt: true,
+	}
+	err = NewRequest(&subtree, "/svc", client, WithSplitDecryption()).Send(context.Background())
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 1 || parseErrors[0].Name != "/svc/keys/a" {
+		t.Fatalf("expected a parse error for the SecureString under the map, got %v", err)
+	}
+	if !reflect.DeepEqual(subtree.Keys, map[string][]byte{"b": []byte("plain")}) {
+		t.Fatalf("expected no ciphertext in the map: %q", subtree.Keys)
+	}
+}
+
+func TestWithStrictSecure(t *testing.T) {
+	client := &fakeClient{
+		parameters: map[string]string{"/svc/Password": "hunter2", "/svc/Token": "t0ken", "/shared/Key": "k3y"},
+		secure:     map[string]bool{"/svc/Password": true},
+	}
+	type service struct {
+		Password string `ssm:"Password,secure"`
+		Token    string `ssm:"Token,secure"`
+	}
+	var v service
+	if err := NewRequest(&v, "/svc", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	v = service{}
+	err := NewRequest(&v, "/svc", client, WithStrictSecure()).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 1 || parseErrors[0].Name != "/svc/Token" {
+		t.Fatalf("expected parse error for plaintext /svc/Token, got %v", err)
+	}
+	if v.Password != "hunter2" || v.Token != "" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	var key struct {
+		Key string `ssm:",self,secure"`
+	}
+	err = NewRequest(&key, "/shared/Key", client, WithStrictSecure()).Send(context.Background())
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/shared/Key" || key.Key != "" {
+		t.Fatalf("expected parse error for plaintext /shared/Key, got %v (%+v)", err, key)
+	}
+}
+
+func TestWithoutDecryption(t *testing.T) {
+	client := &recordingClient{fakeClient: fakeClient{parameters: map[string]string{"/HasTags/Foo": "foo"}}}
+	var v hasTags
+	if err := NewRequest(&v, "/HasTags", client, WithoutDecryption()).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.inputs) != 1 || aws.ToBool(client.inputs[0].WithDecryption) {
+		t.Errorf("fetched with decryption: %+v", client.inputs)
+	}
+
+	client.secure = map[string]bool{"/HasTags/Foo": true}
+	err := NewRequest(&v, "/HasTags", client, WithoutDecryption()).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/HasTags/Foo" {
+		t.Errorf("expected a ParseError for the SecureString, got %v", err)
+	}
+
+	var subtree struct {
+		Keys map[string][]byte `ssm:"keys,optional"`
+	}
+	err = NewRequest(&subtree, "/app", &decryptingClient{fakeClient: fakeClient{
+		parameters: map[string]string{"/app/keys/a": "secret"},
+		secure:     map[string]bool{"/app/keys/a": true},
+	}}, WithoutDecryption()).Send(context.Background())
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/app/keys/a" || subtree.Keys != nil {
+		t.Errorf("expected a ParseError rather than ciphertext in a map, got %v (%v)", err, subtree.Keys)
+	}
+}
+
+// slowKeyClient blocks GetParameter for the names under a slow KMS key until
+// its context is done.
+type slowKeyClient struct {
+	decryptingClient
+	slow map[string]bool
+}
+
+func (c *slowKeyClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	if c.slow[aws.ToString(params.Name)] {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return c.fakeClient.GetParameter(ctx, params, optFns...)
+}
+
+func TestWithIsolateSecureFetches(t *testing.T) {
+	type service struct {
+		Host     string `ssm:"Host"`
+		Password string `ssm:"Password,secure"`
+		Token    string `ssm:"Token,secure"`
+	}
+	client := &slowKeyClient{
+		decryptingClient: decryptingClient{fakeClient: fakeClient{
+			parameters: map[string]string{"/svc/Host": "db.internal", "/svc/Password": "hunter2", "/svc/Token": "t0ken"},
+			secure:     map[string]bool{"/svc/Password": true, "/svc/Token": true},
+		}},
+		slow: map[string]bool{"/svc/Token": true},
+	}
+	var v service
+	req := NewRequest(&v, "/svc", client, WithIsolateSecureFetches(10*time.Millisecond), WithTiming())
+	err := req.Send(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the slow fetch to time out, got %v", err)
+	}
+	if v.Host != "db.internal" || v.Password != "hunter2" || v.Token != "" || !reflect.DeepEqual(client.decryptions, []bool{false}) {
+		t.Fatalf("unexpected result: %+v with decryption %v", v, client.decryptions)
+	}
+	secure := req.Timings().Secure
+	if len(secure) != 2 || secure["/svc/Token"] < 10*time.Millisecond {
+		t.Errorf("unexpected timings: %v", secure)
+	}
+
+	client.slow = nil
+	v = service{}
+	if err := NewRequest(&v, "/svc", client, WithIsolateSecureFetches(0)).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v != (service{Host: "db.internal", Password: "hunter2", Token: "t0ken"}) {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	pathOnly := struct{ PathFetcher }{&client.fakeClient}
+	if _, err := TryNewRequest(&v, "/svc", pathOnly, WithIsolateSecureFetches(0)); err == nil {
+		t.Error("expected an error for a client without GetParameter")
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+// reportDeprecated calls the hook given to WithDeprecationHook for each
+// parameter Send applied whose field is tagged deprecated, in name order.
+func (r *request) reportDeprecated() {
+	if r.config.deprecationHook == nil {
+		return
+	}
+	for _, name := range sortedNames(r.resolved) {
+		if message, ok := r.deprecated[name]; ok {
+			r.config.deprecationHook(name, message)
+		}
+	}
+}
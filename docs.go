@@ -0,0 +1,78 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+// ParamDoc describes how a field is bound to its parameter, for tools that
+// document the configuration they load.
+type ParamDoc struct {
+	// FieldPath is the Go name of the field, with nested fields joined by
+	// ".", and Type its Go type.
+	FieldPath string
+	Type      string
+	// Name is the full name of the parameter, and Kind the kind of decoder
+	// that sets the field, as reported by DescribeBindings.
+	Name string
+	Kind string
+	// Required is whether Send reports the parameter missing if it's not
+	// found. It's false for fields that are optional or have a default.
+	Required bool
+	// Default is the value of the default modifier, if HasDefault.
+	Default    string
+	HasDefault bool
+	// Modifiers holds every modifier of the tag but optional, such as
+	// "secure" or "defaultFrom", mapped to its value, if any.
+	Modifiers map[string]string
+}
+
+// NewRequestWithDocs is like TryNewRequest, but also returns a ParamDoc
+// for each field it binds, in field order, from the same pass over the
+// struct. Fields bound once Send resolves an interface aren't included.
+func NewRequestWithDocs(configurable interface{}, path string, client PathFetcher, opts ...Option) (Request, []ParamDoc, error) {
+	req, err := TryNewRequest(configurable, path, client, append(opts[:len(opts):len(opts)], withDocs())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := req.(*request)
+	return r, append([]ParamDoc(nil), r.docs...), nil
+}
+
+func withDocs() Option {
+	return func(c *requestConfig) {
+		c.docs = true
+	}
+}
+
+// addDoc records the ParamDoc of a field bound by bindField.
+func (r *request) addDoc(fieldPath, typeName string, t fieldTag, bound int) {
+	if len(r.bindings) == bound {
+		// Not bound to a parameter, as for metadata tags.
+		return
+	}
+	b := r.bindings[len(r.bindings)-1]
+	_, required := r.missing[b.name]
+	doc := ParamDoc{
+		FieldPath: fieldPath,
+		Type:      typeName,
+		Name:      b.name,
+		Kind:      b.kind,
+		Required:  required,
+		Modifiers: make(map[string]string, len(t.modifiers)),
+	}
+	doc.Default, doc.HasDefault = t.modifiers["default"]
+	for key, value := range t.modifiers {
+		doc.Modifiers[key] = value
+	}
+	r.docs = append(r.docs, doc)
+}
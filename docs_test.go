@@ -0,0 +1,53 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestNewRequestWithDocs(t *testing.T) {
+	var v struct {
+		Host     string `ssm:"host"`
+		Port     int    `ssm:"port,default=5432"`
+		Password string `ssm:"password,secure,optional"`
+		Untagged string
+	}
+	req, docs, err := NewRequestWithDocs(&v, "/db", &fakeClient{parameters: map[string]string{"/db/host": "db"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ParamDoc{
+		{FieldPath: "Host", Type: "string", Name: "/db/host", Kind: "string", Required: true, Modifiers: map[string]string{}},
+		{FieldPath: "Port", Type: "int", Name: "/db/port", Kind: "int", Default: "5432", HasDefault: true, Modifiers: map[string]string{"default": "5432"}},
+		{FieldPath: "Password", Type: "string", Name: "/db/password", Kind: "string", Modifiers: map[string]string{"secure": ""}},
+	}
+	if !reflect.DeepEqual(docs, want) {
+		t.Errorf("got docs %+v, want %+v", docs, want)
+	}
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Host != "db" || v.Port != 5432 {
+		t.Errorf("unexpected result: %+v", v)
+	}
+
+	var stringsOnly hasTags
+	if _, docs, err := NewRequestWithDocs(&stringsOnly, "/HasTags", &fakeClient{}); err != nil || len(docs) != 2 {
+		t.Errorf("got %d docs and error %v for a struct of strings", len(docs), err)
+	}
+}
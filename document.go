@@ -0,0 +1,344 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// documentRequest is a Request for a struct stored as one JSON document in
+// the parameter name. Each top-level field is bound to the name of its key
+// under name, as in /app/config/host, which Missing and the errors of Send
+// report.
+type documentRequest struct {
+	lock     sync.Mutex
+	done     bool
+	frozen   atomic.Bool
+	name     string
+	client   GetParameterAPIClient
+	value    reflect.Value
+	bindings []binding
+	// keys maps the name of each field to its key in the document, and
+	// fields to its index in the struct.
+	keys     map[string]string
+	fields   map[string]int
+	required map[string]struct{}
+	missing  map[string]struct{}
+	resolved map[string]struct{}
+	document *string
+	// secure is whether the document was fetched as a SecureString.
+	secure bool
+}
+
+// NewDocumentRequest binds the struct configurable points to to the JSON
+// document stored in the parameter fullName, which Send fetches with
+// GetParameter and decodes with json.Unmarshal, json tags and all. It panics
+// if configurable can't be bound.
+//
+// Send reports the top-level fields whose key the document lacks missing,
+// but for those tagged `ssm:",optional"` or `json:",omitempty"`. Nested
+// structs are decoded as encoding/json does, without checking their keys.
+func NewDocumentRequest(configurable interface{}, fullName string, client GetParameterAPIClient) Request {
+	return mustRequest(TryNewDocumentRequest(configurable, fullName, client))
+}
+
+// TryNewDocumentRequest is like NewDocumentRequest, but returns an error
+// rather than panicking when configurable can't be bound.
+func TryNewDocumentRequest(configurable interface{}, fullName string, client GetParameterAPIClient) (Request, error) {
+	v, err := configurableValue(configurable)
+	if err != nil {
+		return nil, err
+	}
+	d := &documentRequest{
+		name:     normalizeName(fullName),
+		client:   client,
+		value:    v,
+		keys:     make(map[string]string),
+		fields:   make(map[string]int),
+		required: make(map[string]struct{}),
+		missing:  make(map[string]struct{}),
+		resolved: make(map[string]struct{}),
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		key, omitEmpty, ok := jsonKey(field)
+		if !ok {
+			continue
+		}
+		name := joinName(d.name, key)
+		d.bindings = append(d.bindings, binding{fieldPath: field.Name, name: name, kind: "json"})
+		d.keys[name] = key
+		d.fields[name] = i
+		if !omitEmpty && !parseTag(field.Tag.Get(tagName)).optional {
+			d.required[name] = struct{}{}
+			d.missing[name] = struct{}{}
+		}
+	}
+	return d, nil
+}
+
+// jsonKey returns the key encoding/json decodes field from, unless field is
+// unexported, embedded or tagged `json:"-"`.
+func jsonKey(field reflect.StructField) (key string, omitEmpty, ok bool) {
+	if field.PkgPath != "" || field.Anonymous {
+		return "", false, false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	key, options, _ := strings.Cut(tag, ",")
+	if key == "" {
+		key = field.Name
+	}
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return key, omitEmpty, true
+}
+
+func (d *documentRequest) Send(ctx context.Context) error {
+	d.frozen.Store(true)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.done {
+		return ErrAlreadySent
+	}
+	d.done = true
+
+	if d.client == nil {
+		if len(d.missing) > 0 {
+			return d.missingError()
+		}
+		return nil
+	}
+	output, err := d.client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(d.name),
+		WithDecryption: aws.Bool(true),
+	})
+	var notFound *types.ParameterNotFound
+	if errors.As(err, &notFound) {
+		d.missing = map[string]struct{}{d.name: {}}
+		return d.missingError()
+	}
+	if err != nil {
+		if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			return &CanceledError{Err: err, Missing: sortedNames(d.missing)}
+		}
+		return err
+	}
+	document := aws.ToString(output.Parameter.Value)
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(document), &keys); err != nil {
+		return ParseErrors{&ParseError{Name: d.name, Err: err}}
+	}
+	if err := json.Unmarshal([]byte(document), d.value.Addr().Interface()); err != nil {
+		return ParseErrors{&ParseError{Name: d.name, Err: err}}
+	}
+	d.document = &document
+	d.secure = output.Parameter.Type == types.ParameterTypeSecureString
+	d.resolved[d.name] = struct{}{}
+	for name, key := range d.keys {
+		if hasKey(keys, key) {
+			delete(d.missing, name)
+		}
+	}
+	if len(d.missing) > 0 {
+		return d.missingError()
+	}
+	return nil
+}
+
+// hasKey reports whether keys holds key, matched as encoding/json matches
+// keys to fields: exactly, or else regardless of case.
+func hasKey(keys map[string]json.RawMessage, key string) bool {
+	if _, ok := keys[key]; ok {
+		return true
+	}
+	for k := range keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// missingError names the fields bound to the missing keys, or every
+// required field when the document itself is missing.
+func (d *documentRequest) missingError() error {
+	fields := make(map[string][]string, len(d.missing))
+	_, noDocument := d.missing[d.name]
+	for _, b := range d.bindings {
+		_, missing := d.missing[b.name]
+		_, required := d.required[b.name]
+		if !missing && !(noDocument && required) {
+			continue
+		}
+		fieldPath := b.fieldPath
+		if typeName := d.value.Type().Name(); typeName != "" {
+			fieldPath = typeName + "." + fieldPath
+		}
+		name := b.name
+		if noDocument {
+			name = d.name
+		}
+		fields[name] = append(fields[name], fieldPath)
+	}
+	return &MissingFieldsError{Missing: sortedNames(d.missing), Fields: fields}
+}
+
+func (d *documentRequest) ParameterNames() []string {
+	return []string{d.name}
+}
+
+func (d *documentRequest) RequiredNames() []string {
+	return []string{d.name}
+}
+
+func (d *documentRequest) OptionalNames() []string {
+	return nil
+}
+
+func (d *documentRequest) Resolved() []string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return sortedNames(d.resolved)
+}
+
+func (d *documentRequest) Missing() []string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return sortedNames(d.missing)
+}
+
+func (d *documentRequest) AssertComplete() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return incompleteError(d.missing, d.bindings)
+}
+
+func (d *documentRequest) DescribeBindings() map[string]string {
+	bindings := make(map[string]string, len(d.bindings))
+	for _, b := range d.bindings {
+		bindings[b.fieldPath] = b.kind
+	}
+	return bindings
+}
+
+func (d *documentRequest) Timings() Timings {
+	return Timings{}
+}
+
+func (d *documentRequest) SendWithProgress(ctx context.Context) (<-chan Progress, func() error) {
+	return newProgressReporter(nil).run(ctx, d)
+}
+
+// Exists reports whether the document exists, not its keys.
+func (d *documentRequest) Exists(ctx context.Context, client ssm.DescribeParametersAPIClient) ([]string, error) {
+	missing := []string{d.name}
+	err := describeNames(ctx, client, missing, func(metadata types.ParameterMetadata) {
+		if normalizeName(aws.ToString(metadata.Name)) == d.name {
+			missing = nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return missing, nil
+}
+
+// Set decodes value as the JSON of the field at fieldPath, as Send decodes
+// the document.
+func (d *documentRequest) Set(fieldPath, value string) error {
+	if d.frozen.Load() {
+		return errSetAfterSend
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.done {
+		return errSetAfterSend
+	}
+	for _, b := range d.bindings {
+		if b.fieldPath != fieldPath {
+			continue
+		}
+		field := d.value.Field(d.fields[b.name])
+		decoded := reflect.New(field.Type())
+		if err := json.Unmarshal([]byte(value), decoded.Interface()); err != nil {
+			return ParseErrors{&ParseError{Name: b.name, Err: err}}
+		}
+		field.Set(decoded.Elem())
+		delete(d.missing, b.name)
+		d.resolved[b.name] = struct{}{}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", errNoField, fieldPath)
+}
+
+// AddParameter panics: a document request fetches its one parameter only.
+func (d *documentRequest) AddParameter(fullName string, set func(value string)) {
+	panic(fmt.Sprintf("can't add %s to the document request for %s", fullName, d.name))
+}
+
+// WriteEnv writes the document as one variable named after the last
+// segment of its name.
+func (d *documentRequest) WriteEnv(w io.Writer) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.done {
+		return errors.New("WriteEnv called before Send")
+	}
+	if d.document == nil {
+		return nil
+	}
+	parent := d.name[:strings.LastIndex(d.name, "/")+1]
+	return writeEnv(w, map[string]string{envName(parent, d.name): *d.document})
+}
+
+// ResolvedValues redacts a document fetched as a SecureString, as
+// RedactSecure does.
+func (d *documentRequest) ResolvedValues() map[string]string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.document == nil {
+		return map[string]string{}
+	}
+	value := *d.document
+	if RedactSecure.redacts(d.secure) {
+		value = RedactedValue
+	}
+	return map[string]string{d.name: value}
+}
+
+func (d *documentRequest) Paths() []RequestPath {
+	if d.client == nil {
+		return nil
+	}
+	return []RequestPath{{Path: d.name, Strategy: FetchByName}}
+}
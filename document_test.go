@@ -0,0 +1,148 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+type serviceDocument struct {
+	Host   string `json:"host"`
+	Port   int    `json:"port"`
+	Tags   []string
+	Debug  bool   `json:"debug,omitempty"`
+	Region string `json:"region" ssm:",optional"`
+	Limits struct {
+		Reads int `json:"reads"`
+	} `json:"limits"`
+	Ignored string `json:"-"`
+}
+
+func TestDocumentRequest(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/app/config":  `{"host": "db.internal", "port": 5432, "tags": ["a", "b"], "limits": {"reads": 10}}`,
+		"/app/partial": `{"host": "db.internal", "Tags": []}`,
+		"/app/invalid": `{"host": 5432}`,
+	}}
+	var v serviceDocument
+	req := NewDocumentRequest(&v, "/app/config", client)
+	if bindings := req.DescribeBindings(); len(bindings) != 6 || bindings["Limits"] != "json" {
+		t.Fatalf("unexpected bindings: %v", bindings)
+	}
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Host != "db.internal" || v.Port != 5432 || !reflect.DeepEqual(v.Tags, []string{"a", "b"}) || v.Limits.Reads != 10 {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+	if resolved := req.Resolved(); !reflect.DeepEqual(resolved, []string{"/app/config"}) {
+		t.Fatalf("unexpected resolved names: %v", resolved)
+	}
+	if err := req.AssertComplete(); err != nil {
+		t.Fatal(err)
+	}
+	if values := req.ResolvedValues(); values["/app/config"] != client.parameters["/app/config"] {
+		t.Fatalf("unexpected values: %v", values)
+	}
+	var env bytes.Buffer
+	if err := req.WriteEnv(&env); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "CONFIG='" + client.parameters["/app/config"] + "'\n"; env.String() != expected {
+		t.Fatalf("unexpected env %q", env.String())
+	}
+
+	v = serviceDocument{}
+	err := NewDocumentRequest(&v, "/app/partial", client).Send(context.Background())
+	var missing *MissingFieldsError
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing.Missing), []string{"/app/partial/limits", "/app/partial/port"}) {
+		t.Fatalf("expected the keys of required fields to be missing, got %v", err)
+	}
+	if err.Error() != "missing ssm parameters: /app/partial/limits (field serviceDocument.Limits), /app/partial/port (field serviceDocument.Port)" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v.Host != "db.internal" {
+		t.Fatalf("expected the keys found to be set: %+v", v)
+	}
+
+	err = NewDocumentRequest(&v, "/app/absent", client).Send(context.Background())
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing.Missing), []string{"/app/absent"}) || len(missing.Fields["/app/absent"]) != 4 {
+		t.Fatalf("expected the document to be missing, got %#v", err)
+	}
+
+	err = NewDocumentRequest(&v, "/app/invalid", client).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/app/invalid" {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+}
+
+func TestDocumentRequestSet(t *testing.T) {
+	var v serviceDocument
+	req := NewDocumentRequest(&v, "/app/config", nil)
+	for fieldPath, value := range map[string]string{"Host": `"db.internal"`, "Port": "5432", "Tags": `["a"]`} {
+		if err := req.Set(fieldPath, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := req.Set("Port", "many"); err == nil {
+		t.Fatal("expected an error setting invalid JSON")
+	}
+	err := req.Send(context.Background())
+	var missing *MissingFieldsError
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing.Missing), []string{"/app/config/limits"}) {
+		t.Fatalf("expected /app/config/limits to be missing, got %v", err)
+	}
+	if v.Host != "db.internal" || v.Port != 5432 {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+	if resolved := req.Resolved(); !reflect.DeepEqual(resolved, []string{"/app/config/Tags", "/app/config/host", "/app/config/port"}) {
+		t.Fatalf("expected the fields set to be resolved, got %v", resolved)
+	}
+	if req.Paths() != nil {
+		t.Fatalf("expected no paths without a client, got %v", req.Paths())
+	}
+	expectPanic(t, func() { req.AddParameter("/app/other", func(string) {}) })
+}
+
+// operationErrorClient fails as the SDK does once its context is done,
+// wrapping the context's error in its own.
+type operationErrorClient struct{}
+
+func (operationErrorClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	return nil, fmt.Errorf("operation error SSM: GetParameter, %w", ctx.Err())
+}
+
+func TestDocumentRequestCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var v serviceDocument
+	err := NewDocumentRequest(&v, "/app/config", operationErrorClient{}).Send(ctx)
+	var canceled *CanceledError
+	if !errors.As(err, &canceled) || !errors.Is(err, context.Canceled) || !strings.Contains(canceled.Err.Error(), "GetParameter") {
+		t.Fatalf("expected a CanceledError wrapping the GetParameter error, got %v", err)
+	}
+	if len(canceled.Missing) != 4 {
+		t.Fatalf("unexpected missing names: %v", canceled.Missing)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// newEndpointClient returns a real SSM client whose requests go to an
+// httptest server answering GetParametersByPath from parameters.
+func newEndpointClient(t *testing.T, parameters map[string]string) *ssm.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target := r.Header.Get("X-Amz-Target"); target != "AmazonSSM.GetParametersByPath" {
+			http.Error(w, "unexpected target "+target, http.StatusBadRequest)
+			return
+		}
+		var input struct {
+			Path string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		type parameter struct {
+			Name, Value, Type string
+		}
+		output := struct {
+			Parameters []parameter
+		}{Parameters: []parameter{}}
+		for name, value := range parameters {
+			if strings.HasPrefix(name, input.Path+"/") {
+				output.Parameters = append(output.Parameters, parameter{name, value, "String"})
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		_ = json.NewEncoder(w).Encode(output)
+	}))
+	t.Cleanup(server.Close)
+
+	return ssm.New(ssm.Options{
+		Region:           "local",
+		EndpointResolver: ssm.EndpointResolverFromURL(server.URL),
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "test", SecretAccessKey: "test"}, nil
+		}),
+	})
+}
+
+func TestSendCustomEndpoint(t *testing.T) {
+	client := newEndpointClient(t, map[string]string{
+		"/HasTags/Foo":         "foo",
+		"/HasTags/OptionalBar": "bar",
+	})
+	var v hasTags
+	if err := NewRequest(&v, "/HasTags", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo" || v.OptionalBar != "bar" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+}
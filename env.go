@@ -0,0 +1,129 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// envName derives the variable name for a parameter from its name relative
+// to path: letters are upper-cased and anything else but digits becomes an
+// underscore, so that /app/db/url under /app is DB_URL.
+func envName(path, name string) string {
+	rest := strings.TrimPrefix(name, strings.TrimSuffix(path, "/")+"/")
+	var b strings.Builder
+	for _, c := range strings.Trim(rest, "/") {
+		switch {
+		case c >= 'a' && c <= 'z':
+			b.WriteRune(c - 'a' + 'A')
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			b.WriteRune(c)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	env := b.String()
+	if env == "" || env[0] >= '0' && env[0] <= '9' {
+		env = "_" + env
+	}
+	return env
+}
+
+// envVars adds the values applied by Send to vars, keyed by variable name.
+// owner records the parameter each variable came from, so that two
+// parameters mapping to the same variable are reported rather than one
+// silently replacing the other. With omitAll, as under RedactAll, nothing is
+// added.
+func (r *request) envVars(vars, owner map[string]string, omitSecure, omitAll bool) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.done {
+		return errors.New("WriteEnv called before Send")
+	}
+	if omitAll || r.config.redactPolicy == RedactAll {
+		return nil
+	}
+	omitSecure = omitSecure || r.config.envOmitSecure
+	path := aws.ToString(r.input.Path)
+	for name, value := range r.values {
+		if omitSecure {
+			if _, ok := r.secure[name]; ok {
+				continue
+			}
+			if _, ok := r.secureStrings[name]; ok {
+				continue
+			}
+		}
+		key := envName(path, name)
+		if other, ok := owner[key]; ok && other != name {
+			return fmt.Errorf("parameters %s and %s both map to %s", other, name, key)
+		}
+		vars[key] = value
+		owner[key] = name
+	}
+	return nil
+}
+
+func writeEnv(w io.Writer, vars map[string]string) error {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", key, shellQuote(vars[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shellQuote leaves value as it is if a shell reads it back as such, and
+// otherwise single-quotes it, which keeps everything literal, line breaks
+// and $ included, but the single quote itself: that ends the quoting to be
+// written as \'.
+func shellQuote(value string) string {
+	if value != "" && strings.Trim(value, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-+./:,@%=") == "" {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func (r *request) WriteEnv(w io.Writer) error {
+	vars := make(map[string]string)
+	if err := r.envVars(vars, make(map[string]string), false, false); err != nil {
+		return err
+	}
+	return writeEnv(w, vars)
+}
+
+// WriteEnv names each variable relative to the path of the request that
+// fetched it.
+func (m *mergedRequest) WriteEnv(w io.Writer) error {
+	vars := make(map[string]string)
+	owner := make(map[string]string)
+	for _, req := range m.leaves() {
+		if err := req.envVars(vars, owner, m.config.envOmitSecure, m.config.redactPolicy == RedactAll); err != nil {
+			return err
+		}
+	}
+	return writeEnv(w, vars)
+}
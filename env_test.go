@@ -0,0 +1,173 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+func TestWriteEnv(t *testing.T) {
+	var v struct {
+		URL      string `ssm:"db/url"`
+		Password string `ssm:"db/password,secure"`
+		Token    string `ssm:"api-token"`
+		Port     int    `ssm:"2nd.port,optional"`
+		Absent   string `ssm:"absent,optional"`
+	}
+	client := &fakeClient{
+		parameters: map[string]string{
+			"/app/db/url":      "postgres://db",
+			"/app/db/password": "hunter2",
+			"/app/api-token":   "t0ken",
+			"/app/2nd.port":    "8080",
+		},
+		secure: map[string]bool{"/app/db/password": true, "/app/api-token": true},
+	}
+
+	input := ssm.GetParametersByPathInput{Path: aws.String("/app"), Recursive: aws.Bool(true)}
+	req := NewRequestWithInput(&v, input, client)
+	var b strings.Builder
+	if err := req.WriteEnv(&b); err == nil {
+		t.Error("expected an error before Send")
+	}
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := req.WriteEnv(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "API_TOKEN=t0ken\nDB_PASSWORD=hunter2\nDB_URL=postgres://db\n_2ND_PORT=8080\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	req = NewRequestWithInput(&v, input, client, WithEnvOmitSecure())
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	b.Reset()
+	if err := req.WriteEnv(&b); err != nil {
+		t.Fatal(err)
+	}
+	want = "DB_URL=postgres://db\n_2ND_PORT=8080\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	req = NewRequestWithInput(&v, input, client, WithRedactPolicy(RedactAll))
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	b.Reset()
+	if err := req.WriteEnv(&b); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != "" {
+		t.Errorf("expected nothing under RedactAll, got %q", got)
+	}
+}
+
+func TestWriteEnvErrors(t *testing.T) {
+	var v struct {
+		A string `ssm:"a-b"`
+		B string `ssm:"a_b"`
+	}
+	client := &fakeClient{parameters: map[string]string{"/app/a-b": "1", "/app/a_b": "2"}}
+	req := NewRequest(&v, "/app", client)
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := req.WriteEnv(&strings.Builder{}); err == nil {
+		t.Error("expected an error for two parameters mapping to A_B")
+	}
+}
+
+func TestWriteEnvQuoting(t *testing.T) {
+	values := map[string]string{
+		"/app/plain":   "postgres://db:5432/app",
+		"/app/empty":   "",
+		"/app/spaces":  "hello world",
+		"/app/quotes":  `it's "quoted"`,
+		"/app/newline": "line1\nline2\n",
+		"/app/dollar":  "$HOME and ${PATH} and `date` and $(id)",
+		"/app/escape":  `back\slash\'`,
+	}
+	req := NewRequest(&struct{}{}, "/app", &fakeClient{parameters: values})
+	for name := range values {
+		req.AddParameter(name, func(string) {})
+	}
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := req.WriteEnv(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "PLAIN=postgres://db:5432/app\n") || !strings.Contains(b.String(), "EMPTY=''\n") {
+		t.Errorf("unexpected quoting:\n%s", b.String())
+	}
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh to source the output")
+	}
+	file := filepath.Join(t.TempDir(), "env")
+	if err := os.WriteFile(file, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range values {
+		key := envName("/app", name)
+		// printf keeps the trailing newlines that $(...) would strip.
+		out, err := exec.Command(sh, "-c", `. "$0" && printf '%s' "$`+key+`"`, file).Output()
+		if err != nil {
+			t.Fatalf("sourcing the output failed: %v\n%s", err, b.String())
+		}
+		if string(out) != want {
+			t.Errorf("%s round-tripped as %q, want %q", key, out, want)
+		}
+	}
+}
+
+func TestMergedWriteEnv(t *testing.T) {
+	var db struct {
+		URL string `ssm:"url"`
+	}
+	var cache struct {
+		TTL string `ssm:"ttl"`
+	}
+	client := &fakeClient{parameters: map[string]string{"/db/url": "postgres://db", "/cache/ttl": "5m"}}
+	merged := MergeRequests([]Request{
+		NewRequest(&db, "/db", client),
+		NewRequest(&cache, "/cache", client),
+	})
+	if err := merged.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := merged.WriteEnv(&b); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "TTL=5m\nURL=postgres://db\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/retailnext/ssmconfig"
+)
+
+// Point the SSM client at LocalStack, or any other endpoint, through the
+// SDK's own configuration. Request doesn't care where its client sends
+// requests.
+func ExampleNewRequest_localStack() {
+	cfg, err := config.LoadDefaultConfig(context.Background(),
+		config.WithRegion("us-east-1"),
+		config.WithEndpointResolverWithOptions(aws.EndpointResolverWithOptionsFunc(
+			func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+				return aws.Endpoint{URL: "http://localhost:4566", HostnameImmutable: true}, nil
+			},
+		)),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var c struct {
+		DBHost string `ssm:"db/host"`
+	}
+	if err := ssmconfig.NewRequest(&c, "/app", ssm.NewFromConfig(cfg)).Send(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(c.DBHost)
+}
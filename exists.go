@@ -0,0 +1,78 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func (r *request) Exists(ctx context.Context, client ssm.DescribeParametersAPIClient) ([]string, error) {
+	r.lock.Lock()
+	names := r.leafNames()
+	r.lock.Unlock()
+
+	missing := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		missing[name] = struct{}{}
+	}
+	err := describeNames(ctx, client, names, func(metadata types.ParameterMetadata) {
+		delete(missing, normalizeName(aws.ToString(metadata.Name)))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sortedNames(missing), nil
+}
+
+// leafNames returns the sorted required names that DescribeParameters can
+// find: neither the roots of subtree, indexed and map fields, which aren't
+// parameters themselves, nor ARNs, which it doesn't accept.
+func (r *request) leafNames() []string {
+	roots := make(map[string]struct{})
+	for _, s := range r.subtrees {
+		roots[s.root] = struct{}{}
+	}
+	for _, l := range r.indexed {
+		roots[l.name] = struct{}{}
+	}
+	for _, m := range r.maps {
+		roots[m.name] = struct{}{}
+	}
+	var names []string
+	for _, name := range sortedNames(r.required) {
+		if _, ok := roots[name]; !ok && !isARN(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (m *mergedRequest) Exists(ctx context.Context, client ssm.DescribeParametersAPIClient) ([]string, error) {
+	missing := make(map[string]struct{})
+	for _, req := range m.requests {
+		names, err := req.Exists(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			missing[name] = struct{}{}
+		}
+	}
+	return sortedNames(missing), nil
+}
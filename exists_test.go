@@ -0,0 +1,68 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestExists(t *testing.T) {
+	client := &metadataClient{metadata: map[string]types.ParameterMetadata{
+		"/HasTags/Foo": {Name: aws.String("/HasTags/Foo")},
+		"/db/host":     {Name: aws.String("/db/host")},
+	}}
+	var v hasTags
+	req := NewRequest(&v, "/HasTags", client)
+	req.AddParameter("/HasTags/Extra", func(string) {})
+	missing, err := req.Exists(context.Background(), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(missing, []string{"/HasTags/Extra"}) {
+		t.Fatalf("unexpected missing parameters: %v", missing)
+	}
+	if !reflect.DeepEqual(client.filters, [][]string{{"/HasTags/Extra", "/HasTags/Foo"}}) {
+		t.Fatalf("expected only required names to be described, got %v", client.filters)
+	}
+
+	var db struct {
+		Host string `ssm:"host"`
+		Port string `ssm:"port"`
+	}
+	merged := MergeRequests([]Request{NewRequest(&v, "/HasTags", client), NewRequest(&db, "/db", client)})
+	if missing, err := merged.Exists(context.Background(), client); err != nil || !reflect.DeepEqual(missing, []string{"/db/port"}) {
+		t.Fatalf("unexpected missing parameters: %v, %v", missing, err)
+	}
+
+	var nested struct {
+		Host   string            `ssm:"host"`
+		Keys   map[string][]byte `ssm:"keys"`
+		Admins []string          `ssm:"admins,indexed"`
+		Shared string            `ssm:"arn:aws:ssm:us-east-1:123456789012:parameter/shared/token"`
+	}
+	client.filters = nil
+	missing, err = NewRequest(&nested, "/db", nil).Exists(context.Background(), client)
+	if err != nil || len(missing) != 0 {
+		t.Fatalf("expected only leaf names to be checked, got %v, %v", missing, err)
+	}
+	if !reflect.DeepEqual(client.filters, [][]string{{"/db/host"}}) {
+		t.Fatalf("expected only /db/host to be described, got %v", client.filters)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"flag"
+	"reflect"
+	"sync"
+)
+
+// stringField is a precompiled binding for a plain string field.
+type stringField struct {
+	fieldPath string
+	suffix    string
+	index     int
+	optional  bool
+}
+
+// stringPlans caches, per struct type, the []stringField plan for structs
+// whose ssm-tagged fields are all plain settable strings without modifiers,
+// or nil for structs that need the general binding path.
+var stringPlans sync.Map
+
+var (
+	setterType    = reflect.TypeOf((*Setter)(nil)).Elem()
+	flagValueType = reflect.TypeOf((*flag.Value)(nil)).Elem()
+)
+
+func stringPlan(t reflect.Type) []stringField {
+	if plan, ok := stringPlans.Load(t); ok {
+		return plan.([]stringField)
+	}
+
+	plan := make([]stringField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get(tagName)
+		if tag == "" {
+			continue
+		}
+		ft := parseTag(tag)
+		if len(ft.modifiers) > 0 || field.PkgPath != "" || field.Type.Kind() != reflect.String || reflect.PtrTo(field.Type).Implements(setterType) || reflect.PtrTo(field.Type).Implements(flagValueType) {
+			plan = nil
+			break
+		}
+		plan = append(plan, stringField{fieldPath: field.Name, suffix: ft.suffix, index: i, optional: ft.optional})
+	}
+
+	stringPlans.Store(t, plan)
+	return plan
+}
+
+func (r *request) bindStrings(v reflect.Value, path string, plan []stringField) {
+	for _, sf := range plan {
+		f := v.Field(sf.index)
+		name := r.config.suffixed(joinName(path, sf.suffix))
+		r.bindings = append(r.bindings, binding{fieldPath: sf.fieldPath, name: name, kind: "string"})
+		r.setters[name] = append(r.setters[name], func(value string) error {
+			f.SetString(value)
+			return nil
+		})
+		if !sf.optional {
+			r.missing[name] = struct{}{}
+		}
+	}
+}
+
+// taggedField is a field with an ssm tag, as parsed once per struct type.
+type taggedField struct {
+	index int
+	field reflect.StructField
+	tag   fieldTag
+}
+
+type fieldPlanKey struct {
+	t               reflect.Type
+	jsonTagFallback bool
+}
+
+// fieldPlans caches the []taggedField plan of each struct type for the
+// general binding path, which then only builds the setters of a new
+// Request. WithJSONTagFallback changes which fields are tagged, so it's part
+// of the key.
+var fieldPlans sync.Map
+
+func fieldPlan(t reflect.Type, config *requestConfig) []taggedField {
+	key := fieldPlanKey{t: t, jsonTagFallback: config.jsonTagFallback}
+	if plan, ok := fieldPlans.Load(key); ok {
+		return plan.([]taggedField)
+	}
+
+	var plan []taggedField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := fieldTagOf(field, config)
+		if tag == "" {
+			continue
+		}
+		plan = append(plan, taggedField{index: i, field: field, tag: parseTag(tag)})
+	}
+
+	fieldPlans.Store(key, plan)
+	return plan
+}
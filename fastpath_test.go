@@ -0,0 +1,145 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type stringOnly struct {
+	A string `ssm:"A"`
+	B string `ssm:"B"`
+	C string `ssm:"C"`
+	D string `ssm:"D"`
+	E string `ssm:"E,optional"`
+	F string `ssm:"F,optional"`
+	G string `ssm:"G"`
+	H string `ssm:"H"`
+}
+
+func TestStringPlan(t *testing.T) {
+	if plan := stringPlan(reflect.TypeOf(stringOnly{})); len(plan) != 8 || !plan[4].optional {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+	if plan := stringPlan(reflect.TypeOf(hasStorage{})); plan != nil {
+		t.Fatalf("expected no plan for struct with interface field: %+v", plan)
+	}
+	if plan := stringPlan(reflect.TypeOf(struct {
+		Name string `ssm:"Name,trimPrefix=x"`
+	}{})); plan != nil {
+		t.Fatalf("expected no plan for struct with modifiers: %+v", plan)
+	}
+}
+
+func TestStringPlanBindsEachInstance(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/one/A": "1", "/one/B": "1", "/one/C": "1", "/one/D": "1", "/one/G": "1", "/one/H": "1",
+		"/two/A": "2", "/two/B": "2", "/two/C": "2", "/two/D": "2", "/two/G": "2", "/two/H": "2",
+	}}
+	var one, two stringOnly
+	if err := NewRequest(&one, "/one", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewRequest(&two, "/two", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if one.A != "1" || one.H != "1" || two.A != "2" || two.H != "2" {
+		t.Fatalf("unexpected results: %+v %+v", one, two)
+	}
+}
+
+func BenchmarkNewRequestStringOnly(b *testing.B) {
+	client := &fakeClient{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v stringOnly
+		NewRequest(&v, "/app", client)
+	}
+}
+
+// BenchmarkNewRequestStringOnlyGeneral binds the same struct through the
+// general path, for comparison with BenchmarkNewRequestStringOnly.
+func BenchmarkNewRequestStringOnlyGeneral(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v stringOnly
+		r := request{
+			missing:  make(map[string]struct{}, 8),
+			resolved: make(map[string]struct{}, 8),
+			setters:  make(map[string][]func(string) error, 8),
+		}
+		if err := r.bind(reflect.ValueOf(&v).Elem(), "/app", ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type mixed struct {
+	Host    string        `ssm:"Host"`
+	Port    int           `ssm:"Port,default=8080"`
+	Debug   bool          `ssm:"Debug,optional"`
+	Timeout time.Duration `ssm:"Timeout,optional"`
+	Hosts   []string      `ssm:"Hosts,optional"`
+	Region  string        `ssm:"Region,path=/common/region,optional"`
+	Token   string        `ssm:"Token,secure,optional"`
+	Name    string        `ssm:"Name,trimPrefix=x-"`
+}
+
+func TestFieldPlanBindsEachInstance(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/one/Host": "one", "/one/Port": "1", "/one/Name": "x-one",
+		"/two/Host": "two", "/two/Debug": "true", "/two/Name": "x-two",
+	}}
+	var one, two mixed
+	reqOne := NewRequest(&one, "/one", client)
+	reqTwo := NewRequest(&two, "/two", client)
+	if err := reqTwo.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := reqOne.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(one, mixed{Host: "one", Port: 1, Name: "one"}) || !reflect.DeepEqual(two, mixed{Host: "two", Port: 8080, Debug: true, Name: "two"}) {
+		t.Fatalf("unexpected results: %+v %+v", one, two)
+	}
+	if plan := fieldPlan(reflect.TypeOf(mixed{}), &requestConfig{}); len(plan) != 8 || plan[1].tag.modifiers["default"] != "8080" {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+}
+
+func BenchmarkNewRequestMixed(b *testing.B) {
+	client := &fakeClient{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v mixed
+		NewRequest(&v, "/app", client)
+	}
+}
+
+// BenchmarkNewRequestMixedUncached parses the tags of every field again for
+// each Request, for comparison with BenchmarkNewRequestMixed.
+func BenchmarkNewRequestMixedUncached(b *testing.B) {
+	client := &fakeClient{}
+	key := fieldPlanKey{t: reflect.TypeOf(mixed{})}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		fieldPlans.Delete(key)
+		var v mixed
+		NewRequest(&v, "/app", client)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// indexedList is a []string field tagged `indexed`, built from the children
+// of name whose last segment is an index: name/0, name/1 and so on.
+type indexedList struct {
+	name  string
+	field reflect.Value
+}
+
+func (r *request) bindIndexed(f reflect.Value, name, fieldPath string, t fieldTag) error {
+	if f.Type() != reflect.TypeOf([]string(nil)) {
+		return errors.New("indexed requires []string")
+	}
+	r.bindings = append(r.bindings, binding{fieldPath: fieldPath, name: name, kind: "indexed"})
+	if !t.optional {
+		r.missing[name] = struct{}{}
+	}
+	r.indexed = append(r.indexed, indexedList{name: name, field: f})
+	return nil
+}
+
+// maxIndexedElements bounds the length of an indexed list without
+// WithMaxListElements, at the most parameters an account can hold.
+const maxIndexedElements = 100000
+
+// resolveIndexed builds every indexed list from the parameters that had no
+// setter during pagination. A list with no elements is left as it was.
+func (r *request) resolveIndexed(unmatched map[string]string, parseErrors ParseErrors) ParseErrors {
+	for _, l := range r.indexed {
+		values := make(map[int]string)
+		last := -1
+		prefix := l.name + "/"
+		for name, value := range unmatched {
+			rest := strings.TrimPrefix(name, prefix)
+			if rest == name {
+				continue
+			}
+			i, ok := parseIndex(rest)
+			if !ok {
+				continue
+			}
+			values[i] = value
+			if i > last {
+				last = i
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		r.fetched[l.name] = struct{}{}
+		delete(r.missing, l.name)
+
+		// The last index comes from a parameter name, so it's checked
+		// before it sizes the list.
+		limit := r.config.maxListElements
+		if limit <= 0 {
+			limit = maxIndexedElements
+		}
+		if last >= limit {
+			parseErrors = append(parseErrors, &ParseError{Name: l.name, Err: fmt.Errorf("index %d exceeds the limit of %d elements", last, limit)})
+			continue
+		}
+		list := make([]string, 0, len(values))
+		if r.config.fillIndexGaps {
+			list = make([]string, last+1)
+		}
+		var err error
+		for i := 0; i <= last && err == nil; i++ {
+			value, ok := values[i]
+			switch {
+			case r.config.fillIndexGaps:
+				list[i] = value
+			case ok:
+				list = append(list, value)
+			default:
+				err = fmt.Errorf("missing index %d (last index is %d)", i, last)
+			}
+		}
+		if err != nil {
+			parseErrors = append(parseErrors, &ParseError{Name: l.name, Err: err})
+			continue
+		}
+		l.field.Set(reflect.ValueOf(list))
+		r.resolved[l.name] = struct{}{}
+	}
+	return parseErrors
+}
+
+// parseIndex accepts only canonical non-negative integers, so that "01" and
+// "+1" aren't taken as a second element 1.
+func parseIndex(segment string) (int, bool) {
+	i, err := strconv.Atoi(segment)
+	if err != nil || i < 0 || strconv.Itoa(i) != segment {
+		return 0, false
+	}
+	return i, true
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestIndexed(t *testing.T) {
+	type team struct {
+		Admins []string `ssm:"admins,indexed"`
+		Extra  []string `ssm:"extra,indexed,optional"`
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/admins/10": "kim",
+		"/app/admins/2":  "lee",
+		"/app/admins/0":  "ada",
+		"/app/admins/1":  "bo",
+		"/app/admins/01": "ignored",
+		"/app/admins/x":  "ignored",
+	}}
+	for i := 3; i < 10; i++ {
+		client.parameters["/app/admins/"+strconv.Itoa(i)] = "user" + strconv.Itoa(i)
+	}
+	var v team
+	req := NewRequest(&v, "/app", client)
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"ada", "bo", "lee", "user3", "user4", "user5", "user6", "user7", "user8", "user9", "kim"}
+	if !reflect.DeepEqual(v.Admins, expected) || v.Extra != nil {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+	if resolved := req.Resolved(); !reflect.DeepEqual(resolved, []string{"/app/admins"}) {
+		t.Fatalf("unexpected resolved names: %v", resolved)
+	}
+
+	v = team{}
+	err := NewRequest(&v, "/empty", client).Send(context.Background())
+	var missing MissingParameters
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/empty/admins"}) {
+		t.Fatalf("expected /empty/admins to be missing, got %v", err)
+	}
+}
+
+func TestIndexedGaps(t *testing.T) {
+	var v struct {
+		Admins []string `ssm:"admins,indexed"`
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/admins/0": "ada",
+		"/app/admins/2": "lee",
+	}}
+	err := NewRequest(&v, "/app", client).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/app/admins" || v.Admins != nil {
+		t.Fatalf("expected parse error for /app/admins, got %v (%+v)", err, v)
+	}
+
+	if err := NewRequest(&v, "/app", client, WithFillIndexGaps()).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v.Admins, []string{"ada", "", "lee"}) {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	var invalid struct {
+		Admins []int `ssm:"admins,indexed"`
+	}
+	if _, err := TryNewRequest(&invalid, "/app", client); err == nil {
+		t.Fatal("expected error for indexed []int")
+	}
+}
+
+func TestIndexedLimit(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/app/admins/0":            "ada",
+		"/app/admins/999999999999": "mallory",
+	}}
+	var v struct {
+		Admins []string `ssm:"admins,indexed"`
+	}
+	err := NewRequest(&v, "/app", client, WithFillIndexGaps()).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/app/admins" || v.Admins != nil {
+		t.Fatalf("expected a parse error for /app/admins, got %v (%+v)", err, v)
+	}
+
+	client.parameters = map[string]string{"/app/admins/0": "ada", "/app/admins/3": "lee"}
+	err = NewRequest(&v, "/app", client, WithFillIndexGaps(), WithMaxListElements(3)).Send(context.Background())
+	if !errors.As(err, &parseErrors) || !strings.Contains(err.Error(), "index 3 exceeds the limit of 3 elements") {
+		t.Fatalf("expected index 3 to exceed the limit, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type subtree struct {
+	root              string
+	fieldPath         string
+	field             reflect.Value
+	factory           func(discriminator string) (interface{}, error)
+	discriminatorName string
+	discriminator     *string
+	bound             bool
+}
+
+func (r *request) bindSubtree(f reflect.Value, name, fieldPath string, t fieldTag, factory func(string) (interface{}, error)) {
+	key := t.modifiers["discriminator"]
+	if key == "" {
+		key = "type"
+	}
+	st := subtree{
+		root:              name,
+		fieldPath:         fieldPath,
+		field:             f,
+		factory:           factory,
+		discriminatorName: joinName(name, key),
+	}
+	r.bindings = append(r.bindings, binding{fieldPath: fieldPath, name: st.discriminatorName, kind: "interface"})
+	r.setters[st.discriminatorName] = append(r.setters[st.discriminatorName], func(value string) error {
+		st.discriminator = &value
+		return nil
+	})
+	if !t.optional {
+		r.missing[st.discriminatorName] = struct{}{}
+	}
+	r.subtrees = append(r.subtrees, &st)
+}
+
+// resolveSubtrees instantiates the concrete value of every subtree whose
+// discriminator was fetched, then applies the parameters that had no setter
+// during pagination. Concrete values may bind further subtrees, which are
+// resolved in turn.
+func (r *request) resolveSubtrees(unmatched map[string]string, parseErrors ParseErrors) ParseErrors {
+	for i := 0; i < len(r.subtrees); i++ {
+		st := r.subtrees[i]
+		if st.bound || st.discriminator == nil {
+			continue
+		}
+		st.bound = true
+
+		concrete, err := st.factory(*st.discriminator)
+		if err == nil {
+			err = r.bindConcrete(st, concrete)
+		}
+		if err != nil {
+			delete(r.resolved, st.discriminatorName)
+			parseErrors = append(parseErrors, &ParseError{Name: st.discriminatorName, Err: err})
+			continue
+		}
+
+		for name, value := range unmatched {
+			if _, ok := r.setters[name]; ok {
+				delete(unmatched, name)
+				parseErrors = r.apply(name, value, parseErrors)
+			}
+		}
+	}
+	return parseErrors
+}
+
+func (r *request) bindConcrete(st *subtree, concrete interface{}) error {
+	cv := reflect.ValueOf(concrete)
+	if !cv.IsValid() || !cv.Type().AssignableTo(st.field.Type()) {
+		return fmt.Errorf("factory for %s returned %T, which is not assignable to %s", st.fieldPath, concrete, st.field.Type())
+	}
+	if cv.Kind() == reflect.Ptr && !cv.IsNil() && cv.Elem().Kind() == reflect.Struct {
+		if err := r.bind(cv.Elem(), st.root, st.fieldPath+"."); err != nil {
+			return err
+		}
+	}
+	st.field.Set(cv)
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type storage interface {
+	Location() string
+}
+
+type s3Storage struct {
+	Bucket string `ssm:"bucket"`
+	Prefix string `ssm:"prefix,optional"`
+}
+
+func (s *s3Storage) Location() string {
+	return "s3://" + s.Bucket + "/" + s.Prefix
+}
+
+type diskStorage struct {
+	Dir string `ssm:"dir"`
+}
+
+func (s *diskStorage) Location() string {
+	return "file://" + s.Dir
+}
+
+func storageFactory(discriminator string) (interface{}, error) {
+	switch discriminator {
+	case "s3":
+		return &s3Storage{}, nil
+	case "disk":
+		return &diskStorage{}, nil
+	}
+	return nil, fmt.Errorf("unknown storage %q", discriminator)
+}
+
+type hasStorage struct {
+	Name    string  `ssm:"name"`
+	Storage storage `ssm:"storage"`
+}
+
+func TestWithInterfaceFactory(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/app/name":           "app",
+		"/app/storage/type":   "s3",
+		"/app/storage/bucket": "my-bucket",
+		"/app/storage/prefix": "logs",
+	}, pageSize: 1}
+	var v hasStorage
+	req := NewRequest(&v, "/app", client, WithInterfaceFactory("Storage", storageFactory))
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Storage == nil || v.Storage.Location() != "s3://my-bucket/logs" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	client.parameters = map[string]string{
+		"/app/name":         "app",
+		"/app/storage/type": "disk",
+	}
+	v = hasStorage{}
+	err := NewRequest(&v, "/app", client, WithInterfaceFactory("Storage", storageFactory)).Send(context.Background())
+	var missing MissingParameters
+	if !errors.As(err, &missing) || len(missing) != 1 || missing[0] != "/app/storage/dir" {
+		t.Fatalf("expected missing /app/storage/dir, got %v", err)
+	}
+}
+
+func TestWithInterfaceFactoryErrors(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/app/name":         "app",
+		"/app/storage/type": "tape",
+	}}
+	var v hasStorage
+	err := NewRequest(&v, "/app", client, WithInterfaceFactory("Storage", storageFactory)).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/app/storage/type" {
+		t.Fatalf("expected parse error for /app/storage/type, got %v", err)
+	}
+
+	err = NewRequest(&v, "/app", client, WithInterfaceFactory("Storage", func(string) (interface{}, error) {
+		return "not a storage", nil
+	})).Send(context.Background())
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/app/storage/type" {
+		t.Fatalf("expected parse error for /app/storage/type, got %v", err)
+	}
+
+	expectPanic(t, func() {
+		NewRequest(&v, "/app", client)
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// bindKeyID records the KMS key a field tagged keyid must be encrypted
+// with, as in `ssm:"Password,secure,keyid=alias/app-secrets"`.
+//
+// GetParametersByPath doesn't return the key of a parameter, so Send
+// fetches it with a DescribeParameters call per 50 such fields, which needs
+// the client to implement ssm.DescribeParametersAPIClient. A parameter
+// encrypted with another key, or whose key can't be described, gets a
+// ParseError. The key matches if it's the same as keyid, or if it's the ARN
+// of keyid: one ending with ":key/" and a key ID, or with ":" and an
+// alias/name.
+func (r *request) bindKeyID(name string, t fieldTag) error {
+	keyID, ok := t.modifiers["keyid"]
+	if !ok {
+		return nil
+	}
+	if !t.has("secure") {
+		return errors.New("keyid without secure")
+	}
+	if keyID == "" {
+		return errors.New("empty keyid")
+	}
+	if _, ok := r.client.(ssm.DescribeParametersAPIClient); !ok {
+		return errors.New("keyid needs a client with DescribeParameters")
+	}
+	r.keyIDs[name] = keyID
+	return nil
+}
+
+// checkKeyIDs returns ParseErrors for the fetched parameters not encrypted
+// with the key their field is tagged with.
+func (r *request) checkKeyIDs(ctx context.Context) error {
+	var names []string
+	for name := range r.keyIDs {
+		if _, ok := r.fetched[name]; ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	described := make(map[string]string, len(names))
+	err := describeNames(ctx, r.client.(ssm.DescribeParametersAPIClient), names, func(metadata types.ParameterMetadata) {
+		described[normalizeName(aws.ToString(metadata.Name))] = aws.ToString(metadata.KeyId)
+	})
+	if err != nil {
+		return err
+	}
+	var parseErrors ParseErrors
+	for _, name := range names {
+		want := r.keyIDs[name]
+		got, ok := described[name]
+		switch {
+		case !ok:
+			parseErrors = append(parseErrors, &ParseError{Name: name, Err: errors.New("can't describe the KMS key")})
+		case !keyMatches(got, want):
+			parseErrors = append(parseErrors, &ParseError{Name: name, Err: fmt.Errorf("encrypted with KMS key %q, not %q", got, want)})
+		}
+	}
+	if len(parseErrors) > 0 {
+		return parseErrors
+	}
+	return nil
+}
+
+// keyMatches reports whether the KMS key got, as DescribeParameters reports
+// it, is want or its ARN: arn:aws:kms:region:account:key/ID for a key ID,
+// and arn:aws:kms:region:account:alias/name for an alias.
+func keyMatches(got, want string) bool {
+	if got == want {
+		return true
+	}
+	if !strings.HasPrefix(got, "arn:") {
+		return false
+	}
+	if strings.HasPrefix(want, "alias/") {
+		return strings.HasSuffix(got, ":"+want)
+	}
+	return strings.HasSuffix(got, ":key/"+want)
+}
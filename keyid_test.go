@@ -0,0 +1,112 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestKeyID(t *testing.T) {
+	const keyARN = "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	client := &metadataClient{
+		fakeClient: fakeClient{
+			parameters: map[string]string{"/app/Password": "hunter2", "/app/Token": "t0ken"},
+			secure:     map[string]bool{"/app/Password": true, "/app/Token": true},
+		},
+		metadata: map[string]types.ParameterMetadata{
+			"/app/Password": {Name: aws.String("/app/Password"), KeyId: aws.String(keyARN)},
+			"/app/Token":    {Name: aws.String("/app/Token"), KeyId: aws.String("alias/aws/ssm")},
+		},
+	}
+
+	var v struct {
+		Password string `ssm:"Password,secure,keyid=1234abcd-12ab-34cd-56ef-1234567890ab"`
+		Token    string `ssm:"Token,secure"`
+	}
+	if err := NewRequest(&v, "/app", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var w struct {
+		Token string `ssm:"Token,secure,keyid=alias/app-secrets"`
+	}
+	err := NewRequest(&w, "/app", client).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 1 || parseErrors[0].Name != "/app/Token" {
+		t.Errorf("expected a ParseError for /app/Token, got %v", err)
+	}
+}
+
+func TestKeyIDInvalid(t *testing.T) {
+	var notSecure struct {
+		Token string `ssm:"Token,keyid=alias/app-secrets"`
+	}
+	if _, err := TryNewRequest(&notSecure, "/app", &metadataClient{}); err == nil {
+		t.Error("expected an error for keyid without secure")
+	}
+	var v struct {
+		Token string `ssm:"Token,secure,keyid=alias/app-secrets"`
+	}
+	if _, err := TryNewRequest(&v, "/app", &fakeClient{}); err == nil {
+		t.Error("expected an error for a client without DescribeParameters")
+	}
+}
+
+func TestKeyMatches(t *testing.T) {
+	const (
+		keyARN   = "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+		aliasARN = "arn:aws:kms:us-east-1:123456789012:alias/app-secrets"
+	)
+	for _, test := range []struct {
+		got, want string
+		matches   bool
+	}{
+		{keyARN, "1234abcd-12ab-34cd-56ef-1234567890ab", true},
+		{keyARN, keyARN, true},
+		{aliasARN, "alias/app-secrets", true},
+		{"alias/app-secrets", "alias/app-secrets", true},
+		{aliasARN, "alias/secrets", false},
+		{aliasARN, "app-secrets", false},
+		{keyARN, "alias/app-secrets", false},
+		{"arn:aws:kms:us-east-1:123456789012:alias/other-app-secrets", "alias/app-secrets", false},
+	} {
+		if matches := keyMatches(test.got, test.want); matches != test.matches {
+			t.Errorf("keyMatches(%q, %q) = %v", test.got, test.want, matches)
+		}
+	}
+}
+
+func TestKeyIDAliasARN(t *testing.T) {
+	client := &metadataClient{
+		fakeClient: fakeClient{
+			parameters: map[string]string{"/app/Token": "t0ken"},
+			secure:     map[string]bool{"/app/Token": true},
+		},
+		metadata: map[string]types.ParameterMetadata{
+			"/app/Token": {Name: aws.String("/app/Token"), KeyId: aws.String("arn:aws:kms:us-east-1:123456789012:alias/app-secrets")},
+		},
+	}
+	var v struct {
+		Token string `ssm:"Token,secure,keyid=alias/app-secrets"`
+	}
+	if err := NewRequest(&v, "/app", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"fmt"
+)
+
+// Load sends a Request for a new T, which may be an anonymous struct for a
+// one-off read:
+//
+//	cfg, err := ssmconfig.Load[struct {
+//		Region string `ssm:"region"`
+//	}](ctx, "/app", client)
+//
+// The T is private to Load until Send has filled it in without error, so a
+// caller never holds one partly loaded: on an error from TryNewRequest or
+// Send, Load returns a nil *T. Used with an atomic.Pointer, as by
+// ReloadableConfig, readers only ever see a complete config. A caller that
+// tolerates missing parameters uses NewRequest instead.
+func Load[T any](ctx context.Context, path string, client PathFetcher, opts ...Option) (*T, error) {
+	v := new(T)
+	req, err := TryNewRequest(v, path, client, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Send(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Chain bootstraps a Request from parameters of its own: it loads the
+// control parameters under path into a new T, as by Load, then calls next
+// with them to build the Request to send, say with the path or options they
+// name.
+//
+//	_, err := ssmconfig.Chain(ctx, "/bootstrap", client, func(control *struct {
+//		Env string `ssm:"env"`
+//	}) (ssmconfig.Request, error) {
+//		return ssmconfig.TryNewRequest(&cfg, "/app/"+control.Env, client)
+//	})
+//
+// It returns the T along with the first error, from loading T, from next or
+// from Send, wrapped to tell apart the first two. The T is nil if it didn't
+// load, and next isn't called.
+func Chain[T any](ctx context.Context, path string, client PathFetcher, next func(control *T) (Request, error), opts ...Option) (*T, error) {
+	control, err := Load[T](ctx, path, client, opts...)
+	if err != nil {
+		return control, fmt.Errorf("control parameters: %w", err)
+	}
+	req, err := next(control)
+	if err != nil {
+		return control, fmt.Errorf("building request: %w", err)
+	}
+	return control, req.Send(ctx)
+}
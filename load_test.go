@@ -0,0 +1,100 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{"/app/region": "us-west-2", "/app/port": "8080"}}
+	cfg, err := Load[struct {
+		Region string `ssm:"region"`
+		Port   int    `ssm:"port"`
+	}](context.Background(), "/app", client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Region != "us-west-2" || cfg.Port != 8080 {
+		t.Errorf("unexpected result: %+v", cfg)
+	}
+
+	if partial, err := Load[struct {
+		Region string `ssm:"region"`
+		Absent string `ssm:"absent"`
+	}](context.Background(), "/app", client); err == nil || partial != nil {
+		t.Errorf("expected only an error for a missing parameter, got %+v", partial)
+	}
+	if _, err := Load[int](context.Background(), "/app", client); err == nil {
+		t.Error("expected an error for a non-struct")
+	}
+}
+
+func TestAnonymousStruct(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{"/app/region": "us-west-2"}}
+	// Two anonymous structs of the same shape are the same type, and share
+	// the cached plan of the fast path.
+	for i := 0; i < 2; i++ {
+		v := struct {
+			Region string `ssm:"region"`
+		}{}
+		if err := NewRequest(&v, "/app", client).Send(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if v.Region != "us-west-2" {
+			t.Errorf("got Region %q, want us-west-2", v.Region)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/bootstrap/env": "prod",
+		"/app/prod/Foo":  "foo",
+	}}
+	type control struct {
+		Env string `ssm:"env"`
+	}
+	var v hasTags
+	c, err := Chain(context.Background(), "/bootstrap", client, func(c *control) (Request, error) {
+		return TryNewRequest(&v, "/app/"+c.Env, client)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Env != "prod" || v.Foo != "foo" {
+		t.Errorf("unexpected result: %+v %+v", c, v)
+	}
+
+	called := false
+	c, err = Chain(context.Background(), "/missing", client, func(c *control) (Request, error) {
+		called = true
+		return TryNewRequest(&v, "/app/"+c.Env, client)
+	})
+	var missing MissingParameters
+	if !errors.As(err, &missing) || called || c != nil {
+		t.Errorf("expected the control parameters to be missing, without calling next: %v", err)
+	}
+
+	_, err = Chain(context.Background(), "/bootstrap", client, func(c *control) (Request, error) {
+		return TryNewRequest(&v, "/app/staging", client)
+	})
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/app/staging/Foo"}) {
+		t.Errorf("expected /app/staging/Foo to be missing, got %v", err)
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+type mergedRequest struct {
+	lock     sync.Mutex
+	done     bool
+	frozen   atomic.Bool
+	config   requestConfig
+	requests []Request
+	total    time.Duration
+}
+
+// MergeRequests combines requests, typically for different paths of the same
+// configurable, into a single Request. Send sends each of them, in order
+// unless WithParallelPaths is given, and combines their missing and parse
+// errors. Any other error is returned as-is, preferring earlier requests.
+// WithApplyLock applies to each of the requests not built with their own.
+func MergeRequests(requests []Request, opts ...Option) Request {
+	m := mergedRequest{
+		config:   newRequestConfig(opts),
+		requests: requests,
+	}
+	// Requests sent in parallel may set the same configurable, so they
+	// share a lock to apply with, which is WithApplyLock's if given.
+	applyLock := m.config.applyLock
+	if applyLock == nil && m.config.parallelPaths > 1 {
+		applyLock = new(sync.Mutex)
+	}
+	if applyLock != nil {
+		for _, req := range requests {
+			if r, ok := req.(*request); ok && r.applyLock == nil {
+				r.applyLock = applyLock
+				r.config.applyLock = m.config.applyLock
+			}
+		}
+	}
+	return &m
+}
+
+func (m *mergedRequest) Send(ctx context.Context) error {
+	m.frozen.Store(true)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.done {
+		return ErrAlreadySent
+	}
+	m.done = true
+
+	if m.config.timing {
+		start := time.Now()
+		defer func() {
+			m.total = time.Since(start)
+		}()
+	}
+
+	if m.config.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.config.timeout)
+		defer cancel()
+	}
+
+	errs := make([]error, len(m.requests))
+	if m.config.parallelPaths > 1 {
+		sem := make(chan struct{}, m.config.parallelPaths)
+		var wg sync.WaitGroup
+		for i, req := range m.requests {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, req Request) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				errs[i] = req.Send(ctx)
+			}(i, req)
+		}
+		wg.Wait()
+	} else {
+		for i, req := range m.requests {
+			errs[i] = req.Send(ctx)
+		}
+	}
+
+	return mergeErrors(errs)
+}
+
+func mergeErrors(errs []error) error {
+	var missing MissingParameters
+	var fields map[string][]string
+	var parseErrors ParseErrors
+	for _, err := range errs {
+		switch err := err.(type) {
+		case nil:
+		case MissingParameters:
+			missing = append(missing, err...)
+		case *MissingFieldsError:
+			missing = append(missing, err.Missing...)
+			if fields == nil {
+				fields = make(map[string][]string)
+			}
+			for name, paths := range err.Fields {
+				fields[name] = append(fields[name], paths...)
+			}
+		case ParseErrors:
+			parseErrors = append(parseErrors, err...)
+		default:
+			return err
+		}
+	}
+
+	if len(parseErrors) > 0 {
+		sort.SliceStable(parseErrors, func(i, j int) bool {
+			return parseErrors[i].Name < parseErrors[j].Name
+		})
+		return parseErrors
+	}
+	if len(missing) > 0 {
+		missing = unionNames([]string(missing))
+		if fields == nil {
+			return missing
+		}
+		return &MissingFieldsError{Missing: missing, Fields: fields}
+	}
+	return nil
+}
+
+func (m *mergedRequest) ParameterNames() []string {
+	return m.union(Request.ParameterNames)
+}
+
+func (m *mergedRequest) RequiredNames() []string {
+	return m.union(Request.RequiredNames)
+}
+
+// OptionalNames leaves out the names another request requires.
+func (m *mergedRequest) OptionalNames() []string {
+	required := make(map[string]struct{})
+	for _, name := range m.RequiredNames() {
+		required[name] = struct{}{}
+	}
+	var names []string
+	for _, name := range m.union(Request.OptionalNames) {
+		if _, ok := required[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (m *mergedRequest) Resolved() []string {
+	return m.union(Request.Resolved)
+}
+
+func (m *mergedRequest) Missing() []string {
+	return m.union(Request.Missing)
+}
+
+func (m *mergedRequest) AssertComplete() error {
+	var merged IncompleteError
+	for _, req := range m.requests {
+		err := req.AssertComplete()
+		if err == nil {
+			continue
+		}
+		var incomplete *IncompleteError
+		if !errors.As(err, &incomplete) {
+			return err
+		}
+		merged.Fields = unionNames(merged.Fields, incomplete.Fields)
+		merged.Missing = unionNames(merged.Missing, incomplete.Missing)
+	}
+	if len(merged.Missing) == 0 {
+		return nil
+	}
+	return &merged
+}
+
+func (m *mergedRequest) DescribeBindings() map[string]string {
+	bindings := make(map[string]string)
+	for _, req := range m.requests {
+		for fieldPath, kind := range req.DescribeBindings() {
+			bindings[fieldPath] = kind
+		}
+	}
+	return bindings
+}
+
+// Timings concatenates the pages of every request, in order, and collects
+// their secure fetches. Total is the whole of Send when MergeRequests was
+// given WithTiming.
+func (m *mergedRequest) Timings() Timings {
+	var timings Timings
+	for _, req := range m.requests {
+		t := req.Timings()
+		timings.Pages = append(timings.Pages, t.Pages...)
+		for name, d := range t.Secure {
+			if timings.Secure == nil {
+				timings.Secure = make(map[string]time.Duration)
+			}
+			timings.Secure[name] = d
+		}
+	}
+	m.lock.Lock()
+	timings.Total = m.total
+	m.lock.Unlock()
+	return timings
+}
+
+// Set sets the field in the first of the merged requests that has it.
+func (m *mergedRequest) Set(fieldPath, value string) error {
+	if m.frozen.Load() {
+		return errSetAfterSend
+	}
+	for _, req := range m.requests {
+		if err := req.Set(fieldPath, value); !errors.Is(err, errNoField) {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: %s", errNoField, fieldPath)
+}
+
+// AddParameter adds the parameter to the first of the merged requests whose
+// path it's under, or else to the first request, which fetches it by name.
+func (m *mergedRequest) AddParameter(fullName string, set func(value string)) {
+	if m.frozen.Load() {
+		panic(errAddAfterSend)
+	}
+	name := normalizeName(fullName)
+	for _, req := range m.requests {
+		if r, ok := req.(*request); ok && underPath(normalizeName(aws.ToString(r.input.Path)), name, aws.ToBool(r.input.Recursive)) {
+			r.AddParameter(name, set)
+			return
+		}
+	}
+	if len(m.requests) == 0 {
+		panic("no merged requests to add a parameter to")
+	}
+	m.requests[0].AddParameter(name, set)
+}
+
+func (m *mergedRequest) union(names func(Request) []string) []string {
+	lists := make([][]string, 0, len(m.requests))
+	for _, req := range m.requests {
+		lists = append(lists, names(req))
+	}
+	return unionNames(lists...)
+}
+
+func unionNames(lists ...[]string) []string {
+	set := make(map[string]struct{})
+	for _, names := range lists {
+		for _, name := range names {
+			set[name] = struct{}{}
+		}
+	}
+	return sortedNames(set)
+}
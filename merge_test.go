@@ -0,0 +1,211 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+type concurrencyClient struct {
+	fakeClient
+	lock    sync.Mutex
+	active  int
+	maxSeen int
+	arrived chan struct{}
+	release chan struct{}
+}
+
+func (c *concurrencyClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	c.lock.Lock()
+	c.active++
+	if c.active > c.maxSeen {
+		c.maxSeen = c.active
+	}
+	c.lock.Unlock()
+	c.arrived <- struct{}{}
+	defer func() {
+		c.lock.Lock()
+		c.active--
+		c.lock.Unlock()
+	}()
+	<-c.release
+	return c.fakeClient.GetParametersByPath(ctx, params, optFns...)
+}
+
+func TestMergeRequests(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/db/host":    "db.internal",
+		"/db/port":    "5432",
+		"/cache/url":  "redis://cache",
+		"/cache/port": "6379",
+	}}
+	var db struct {
+		Host string `ssm:"host"`
+		Port int    `ssm:"port"`
+	}
+	var cache struct {
+		URL   string `ssm:"url"`
+		Extra string `ssm:"extra,optional"`
+	}
+	req := MergeRequests([]Request{
+		NewRequest(&db, "/db", client),
+		NewRequest(&cache, "/cache", client),
+	})
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if db.Host != "db.internal" || db.Port != 5432 || cache.URL != "redis://cache" {
+		t.Fatalf("unexpected result: %+v %+v", db, cache)
+	}
+	expected := []string{"/cache/extra", "/cache/url", "/db/host", "/db/port"}
+	if names := req.ParameterNames(); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("unexpected parameter names: %v", names)
+	}
+	if names := req.RequiredNames(); !reflect.DeepEqual(names, []string{"/cache/url", "/db/host", "/db/port"}) {
+		t.Fatalf("unexpected required names: %v", names)
+	}
+	if names := req.OptionalNames(); !reflect.DeepEqual(names, []string{"/cache/extra"}) {
+		t.Fatalf("unexpected optional names: %v", names)
+	}
+	if resolved := req.Resolved(); !reflect.DeepEqual(resolved, []string{"/cache/url", "/db/host", "/db/port"}) {
+		t.Fatalf("unexpected resolved names: %v", resolved)
+	}
+}
+
+func TestMergeRequestsErrors(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{"/b/Foo": "foo"}}
+	var a, b hasTags
+	err := MergeRequests([]Request{
+		NewRequest(&a, "/a", client),
+		NewRequest(&b, "/b", client),
+		NewRequest(&hasTags{}, "/c", client),
+	}).Send(context.Background())
+	var missing MissingParameters
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/a/Foo", "/c/Foo"}) {
+		t.Fatalf("expected combined missing parameters, got %v", err)
+	}
+	if want := "missing ssm parameters: /a/Foo (field hasTags.Foo), /c/Foo (field hasTags.Foo)"; err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+}
+
+func TestWithParallelPaths(t *testing.T) {
+	client := &concurrencyClient{
+		fakeClient: fakeClient{parameters: map[string]string{
+			"/a/Foo": "a", "/b/Foo": "b", "/c/Foo": "c", "/d/Foo": "d",
+		}},
+		arrived: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	// All four requests bind the same field, so setters would race if they
+	// weren't serialized.
+	var v hasTags
+	var requests []Request
+	for _, path := range []string{"/a", "/b", "/c", "/d"} {
+		requests = append(requests, NewRequest(&v, path, client))
+	}
+	done := make(chan error)
+	go func() {
+		done <- MergeRequests(requests, WithParallelPaths(2)).Send(context.Background())
+	}()
+	for batch := 0; batch < 2; batch++ {
+		<-client.arrived
+		<-client.arrived
+		client.release <- struct{}{}
+		client.release <- struct{}{}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if client.maxSeen != 2 {
+		t.Fatalf("expected 2 concurrent fetches, saw %d", client.maxSeen)
+	}
+	if v.Foo == "" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+}
+
+func TestMergedOptionalNames(t *testing.T) {
+	client := &fakeClient{}
+	var a struct {
+		Region string `ssm:"region,optional"`
+	}
+	var b struct {
+		Region string `ssm:"region"`
+		Zone   string `ssm:"zone,optional"`
+	}
+	req := MergeRequests([]Request{
+		NewRequest(&a, "/shared", client),
+		NewRequest(&b, "/shared", client),
+	})
+	if names := req.RequiredNames(); !reflect.DeepEqual(names, []string{"/shared/region"}) {
+		t.Fatalf("unexpected required names: %v", names)
+	}
+	if names := req.OptionalNames(); !reflect.DeepEqual(names, []string{"/shared/zone"}) {
+		t.Fatalf("expected a name one request requires to be left out, got %v", names)
+	}
+}
+
+func TestMergedAddParameter(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/db/host":   "db.internal",
+		"/cache/url": "redis://cache",
+		"/cache/ttl": "60",
+	}}
+	var db struct {
+		Host string `ssm:"host"`
+	}
+	var cache struct {
+		URL string `ssm:"url"`
+	}
+	dbRequest := NewRequest(&db, "/db", client)
+	cacheRequest := NewRequest(&cache, "/cache", client)
+	merged := MergeRequests([]Request{dbRequest, cacheRequest})
+
+	var ttl string
+	merged.AddParameter("/cache/ttl", func(value string) { ttl = value })
+	if names := cacheRequest.ParameterNames(); !reflect.DeepEqual(names, []string{"/cache/ttl", "/cache/url"}) {
+		t.Fatalf("expected /cache/ttl to be added to the /cache request, got %v", names)
+	}
+	if err := merged.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if ttl != "60" {
+		t.Fatalf("unexpected ttl %q", ttl)
+	}
+}
+
+func TestMergedAssertComplete(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{"/db/Foo": "foo"}}
+	var db, cache hasTags
+	req := MergeRequests([]Request{
+		NewRequest(&db, "/db", client),
+		NewRequest(&cache, "/cache", client),
+	})
+	_ = req.Send(context.Background())
+	var incomplete *IncompleteError
+	if err := req.AssertComplete(); !errors.As(err, &incomplete) {
+		t.Fatalf("expected an *IncompleteError, got %v", err)
+	}
+	if want := []string{"Foo (/cache/Foo)"}; !reflect.DeepEqual(incomplete.Fields, want) {
+		t.Errorf("got fields %v, want %v", incomplete.Fields, want)
+	}
+}
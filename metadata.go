@@ -0,0 +1,174 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// MetadataAPIClient is the subset of *ssm.Client used by LoadWithMetadata.
+type MetadataAPIClient interface {
+	PathFetcher
+	ssm.DescribeParametersAPIClient
+}
+
+var _ MetadataAPIClient = (*ssm.Client)(nil)
+
+// describeBatchSize is the most values a DescribeParameters filter accepts.
+const describeBatchSize = 50
+
+// metadataField is a field tagged lastModifiedBy or version, which is set
+// from its parameter's metadata rather than its value.
+type metadataField struct {
+	name  string
+	field reflect.Value
+	tag   fieldTag
+}
+
+func isMetadataTag(t fieldTag) bool {
+	return t.has("lastModifiedBy") || t.has("version")
+}
+
+// LoadWithMetadata sends a Request for configurable, then sets the fields
+// tagged lastModifiedBy (a string) or version (an integer) from the
+// metadata of the parameter they name, as in:
+//
+//	Secret           string `ssm:"Secret"`
+//	SecretModifiedBy string `ssm:"Secret,lastModifiedBy"`
+//	SecretVersion    int64  `ssm:"Secret,version"`
+//
+// The metadata costs a DescribeParameters call per 50 such parameters, and
+// the ssm:DescribeParameters permission. Requests built otherwise leave
+// these fields alone. Names are resolved as for NewRequest, expanding
+// WithTagVariables and mirrored by WithNamePrefix and WithNameSuffix.
+func LoadWithMetadata(ctx context.Context, configurable interface{}, path string, client MetadataAPIClient, opts ...Option) error {
+	req, err := TryNewRequest(configurable, path, client, opts...)
+	if err != nil {
+		return err
+	}
+	config := newRequestConfig(opts)
+	path, err = config.expandPath(normalizeName(path))
+	if err != nil {
+		return err
+	}
+	fields, err := metadataFields(configurable, config.prefixed(path), &config)
+	if err != nil {
+		return err
+	}
+	if err := req.Send(ctx); err != nil {
+		return err
+	}
+
+	byName := make(map[string][]metadataField, len(fields))
+	for _, f := range fields {
+		byName[f.name] = append(byName[f.name], f)
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return describeNames(ctx, client, names, func(metadata types.ParameterMetadata) {
+		for _, f := range byName[normalizeName(aws.ToString(metadata.Name))] {
+			f.set(metadata)
+		}
+	})
+}
+
+// describeNames calls found with the metadata of each of names that exists.
+func describeNames(ctx context.Context, client ssm.DescribeParametersAPIClient, names []string, found func(types.ParameterMetadata)) error {
+	for start := 0; start < len(names); start += describeBatchSize {
+		end := start + describeBatchSize
+		if end > len(names) {
+			end = len(names)
+		}
+		paginator := ssm.NewDescribeParametersPaginator(client, &ssm.DescribeParametersInput{
+			ParameterFilters: []types.ParameterStringFilter{{
+				Key:    aws.String("Name"),
+				Option: aws.String("Equals"),
+				Values: names[start:end],
+			}},
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return err
+			}
+			for _, metadata := range page.Parameters {
+				found(metadata)
+			}
+		}
+	}
+	return nil
+}
+
+func metadataFields(configurable interface{}, path string, config *requestConfig) ([]metadataField, error) {
+	v, err := configurableValue(configurable)
+	if err != nil {
+		return nil, err
+	}
+	var fields []metadataField
+	positions := make(map[string]int)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag := fieldTagOf(field, config)
+		if tag == "" {
+			continue
+		}
+		t := parseTag(tag)
+		if !isMetadataTag(t) {
+			continue
+		}
+		if err := t.expand(config.tagVariables); err != nil {
+			return nil, fmt.Errorf("invalid field with ssm tag (%v): %s", err, field.Name)
+		}
+		f := v.Field(i)
+		switch {
+		case t.has("lastModifiedBy") && f.Kind() != reflect.String:
+			return nil, fmt.Errorf("invalid field with ssm tag (lastModifiedBy on non-string field): %s", field.Name)
+		case t.has("version") && !isIntKind(f.Kind()):
+			return nil, fmt.Errorf("invalid field with ssm tag (version on non-integer field): %s", field.Name)
+		}
+		name, err := config.fieldName(path, t, positions)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field with ssm tag (%v): %s", err, field.Name)
+		}
+		fields = append(fields, metadataField{name: config.suffixed(name), field: f, tag: t})
+	}
+	return fields, nil
+}
+
+func (f metadataField) set(metadata types.ParameterMetadata) {
+	if f.tag.has("lastModifiedBy") {
+		f.field.SetString(aws.ToString(metadata.LastModifiedUser))
+	} else {
+		f.field.SetInt(metadata.Version)
+	}
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+type metadataClient struct {
+	fakeClient
+	metadata map[string]types.ParameterMetadata
+	filters  [][]string
+}
+
+func (c *metadataClient) DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+	var output ssm.DescribeParametersOutput
+	for _, filter := range params.ParameterFilters {
+		c.filters = append(c.filters, filter.Values)
+		for _, name := range filter.Values {
+			if metadata, ok := c.metadata[name]; ok {
+				output.Parameters = append(output.Parameters, metadata)
+			}
+		}
+	}
+	return &output, nil
+}
+
+func TestLoadWithMetadata(t *testing.T) {
+	client := &metadataClient{
+		fakeClient: fakeClient{parameters: map[string]string{"/app/Secret": "hunter2", "/app/Other": "other"}},
+		metadata: map[string]types.ParameterMetadata{
+			"/app/Secret": {Name: aws.String("/app/Secret"), LastModifiedUser: aws.String("arn:aws:iam::123456789012:user/ada"), Version: 3},
+		},
+	}
+	var v struct {
+		Secret           string `ssm:"Secret"`
+		SecretModifiedBy string `ssm:"Secret,lastModifiedBy"`
+		SecretVersion    int64  `ssm:"Secret,version"`
+		Other            string `ssm:"Other"`
+	}
+	if err := LoadWithMetadata(context.Background(), &v, "/app", client); err != nil {
+		t.Fatal(err)
+	}
+	if v.Secret != "hunter2" || v.SecretModifiedBy != "arn:aws:iam::123456789012:user/ada" || v.SecretVersion != 3 || v.Other != "other" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+	if !reflect.DeepEqual(client.filters, [][]string{{"/app/Secret"}}) {
+		t.Fatalf("unexpected DescribeParameters filters: %v", client.filters)
+	}
+
+	v.SecretVersion = 0
+	if err := NewRequest(&v, "/app", client).Send(context.Background()); err != nil || v.SecretVersion != 0 {
+		t.Fatalf("expected Send to leave metadata fields alone, got %+v, %v", v, err)
+	}
+
+	var invalid struct {
+		Secret string `ssm:"Secret,version"`
+	}
+	if err := LoadWithMetadata(context.Background(), &invalid, "/app", client); err == nil {
+		t.Fatal("expected error for version on a string field")
+	}
+}
+
+func TestLoadWithMetadataMirrored(t *testing.T) {
+	client := &metadataClient{
+		fakeClient: fakeClient{parameters: map[string]string{"/green/app/Secret-blue": "hunter2", "/green/shared/token-blue": "t0ken"}},
+		metadata: map[string]types.ParameterMetadata{
+			"/green/app/Secret-blue":   {Name: aws.String("/green/app/Secret-blue"), Version: 3},
+			"/green/shared/token-blue": {Name: aws.String("/green/shared/token-blue"), Version: 7},
+		},
+	}
+	var v struct {
+		Secret        string `ssm:"Secret"`
+		SecretVersion int64  `ssm:"Secret,version"`
+		Token         string `ssm:",path=/shared/token"`
+		TokenVersion  int64  `ssm:",path=/shared/token,version"`
+	}
+	if err := LoadWithMetadata(context.Background(), &v, "/app", client, WithNamePrefix("/green"), WithNameSuffix("-blue")); err != nil {
+		t.Fatal(err)
+	}
+	if v.SecretVersion != 3 || v.TokenVersion != 7 {
+		t.Fatalf("unexpected result: %+v (filters %v)", v, client.filters)
+	}
+}
+
+func TestLoadWithMetadataTagVariables(t *testing.T) {
+	client := &metadataClient{
+		fakeClient: fakeClient{parameters: map[string]string{"/prod/Secret": "hunter2", "/prod/shared/token": "t0ken"}},
+		metadata: map[string]types.ParameterMetadata{
+			"/prod/Secret":       {Name: aws.String("/prod/Secret"), Version: 3},
+			"/prod/shared/token": {Name: aws.String("/prod/shared/token"), Version: 7},
+		},
+	}
+	var v struct {
+		Secret        string `ssm:"Secret"`
+		SecretVersion int64  `ssm:"Secret,version"`
+		TokenVersion  int64  `ssm:",path=/${ENV}/shared/token,version"`
+	}
+	if err := LoadWithMetadata(context.Background(), &v, "/${ENV}", client, WithTagVariables(map[string]string{"ENV": "prod"})); err != nil {
+		t.Fatal(err)
+	}
+	if v.SecretVersion != 3 || v.TokenVersion != 7 {
+		t.Fatalf("unexpected result: %+v (filters %v)", v, client.filters)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithNamePrefix(t *testing.T) {
+	type config struct {
+		Foo    string `ssm:"Foo"`
+		Region string `ssm:"Region,path=/common/region"`
+		Absent string `ssm:"Absent"`
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/Foo":             "blue",
+		"/green/app/Foo":       "green",
+		"/green/common/region": "us-west-2",
+		"/green/shared/flag":   "on",
+		"/common/region":       "ignored",
+	}}
+	var v config
+	var flag string
+	req := NewRequest(&v, "/app", client, WithNamePrefix("green"))
+	req.AddParameter("/shared/flag", func(value string) { flag = value })
+	err := req.Send(context.Background())
+	var missing MissingParameters
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/green/app/Absent"}) {
+		t.Fatalf("expected /green/app/Absent to be missing, got %v", err)
+	}
+	if v.Foo != "green" || v.Region != "us-west-2" || flag != "on" {
+		t.Errorf("unexpected result: %+v, flag %q", v, flag)
+	}
+	if resolved := req.Resolved(); !reflect.DeepEqual(resolved, []string{"/green/app/Foo", "/green/common/region", "/green/shared/flag"}) {
+		t.Errorf("unexpected resolved names: %v", resolved)
+	}
+}
+
+func TestWithNameSuffix(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/app/Foo":       "blue",
+		"/app/Foo-green": "green",
+	}}
+	var v hasTags
+	req := NewRequest(&v, "/app", client, WithNameSuffix("-green"))
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "green" || v.OptionalBar != "" {
+		t.Errorf("unexpected result: %+v", v)
+	}
+	if names := req.ParameterNames(); !reflect.DeepEqual(names, []string{"/app/Foo-green", "/app/OptionalBar-green"}) {
+		t.Errorf("unexpected names: %v", names)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// MultiEnvRequest fetches the parameters of several environments laid out as
+// <base>/<env>/... in one pass, and applies one of them to configurable once
+// Select picks it.
+type MultiEnvRequest struct {
+	lock         sync.Mutex
+	configurable interface{}
+	base         string
+	envs         map[string]struct{}
+	client       PathFetcher
+	opts         []Option
+	snapshot     *snapshot
+}
+
+func NewMultiEnvRequest(configurable interface{}, base string, envs []string, client PathFetcher, opts ...Option) *MultiEnvRequest {
+	m := MultiEnvRequest{
+		configurable: configurable,
+		base:         normalizeName(base),
+		envs:         make(map[string]struct{}, len(envs)),
+		client:       client,
+		opts:         opts,
+	}
+	for _, env := range envs {
+		m.envs[strings.Trim(env, "/")] = struct{}{}
+	}
+	return &m
+}
+
+// Send fetches the parameters of every environment. It does not modify
+// configurable.
+func (m *MultiEnvRequest) Send(ctx context.Context) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	input := ssm.GetParametersByPathInput{
+		Path:           &m.base,
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	}
+	s := snapshot{Path: m.base}
+	paginator := ssm.NewGetParametersByPathPaginator(m.client, &input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, parameter := range page.Parameters {
+			name := normalizeName(aws.ToString(parameter.Name))
+			// The base is normalized, so only / ends with a slash.
+			env, _, _ := strings.Cut(strings.TrimPrefix(name, strings.TrimSuffix(m.base, "/")+"/"), "/")
+			if _, ok := m.envs[env]; !ok {
+				continue
+			}
+			s.Parameters = append(s.Parameters, snapshotParameter{
+				Name:    name,
+				Type:    string(parameter.Type),
+				Value:   aws.ToString(parameter.Value),
+				Version: parameter.Version,
+			})
+		}
+	}
+	m.snapshot = &s
+	return nil
+}
+
+// Select applies the parameters fetched for env to configurable, returning
+// the same errors a Request for <base>/<env> would have.
+func (m *MultiEnvRequest) Select(env string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	env = strings.Trim(env, "/")
+	if _, ok := m.envs[env]; !ok {
+		return fmt.Errorf("unknown environment %q", env)
+	}
+	if m.snapshot == nil {
+		return fmt.Errorf("environment %q selected before Send", env)
+	}
+	r, err := TryNewRequest(m.configurable, joinName(m.base, env), &snapshotClient{snapshot: *m.snapshot}, m.opts...)
+	if err != nil {
+		return err
+	}
+	return r.Send(context.Background())
+}
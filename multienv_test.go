@@ -0,0 +1,95 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMultiEnvRequest(t *testing.T) {
+	client := &recordingClient{fakeClient: fakeClient{parameters: map[string]string{
+		"/app/prod/Foo":        "prod-foo",
+		"/app/prod/Nested/Foo": "nested",
+		"/app/dev/Foo":         "dev-foo",
+		"/app/dev/OptionalBar": "dev-bar",
+		"/app/test/Foo":        "test-foo",
+	}}}
+	var v hasTags
+	m := NewMultiEnvRequest(&v, "/app", []string{"prod", "dev"}, client)
+	if err := m.Select("prod"); err == nil {
+		t.Fatal("expected error selecting before Send")
+	}
+	if err := m.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.inputs) != 1 {
+		t.Fatalf("expected a single fetch, got %d", len(client.inputs))
+	}
+	if v != (hasTags{}) {
+		t.Fatalf("Send modified configurable: %+v", v)
+	}
+
+	if err := m.Select("dev"); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "dev-foo" || v.OptionalBar != "dev-bar" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	v = hasTags{}
+	if err := m.Select("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "prod-foo" || v.OptionalBar != "" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	if err := m.Select("test"); err == nil {
+		t.Fatal("expected error selecting unknown environment")
+	}
+}
+
+func TestMultiEnvRequestMissing(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{"/app/dev/OptionalBar": "bar"}}
+	var v hasTags
+	m := NewMultiEnvRequest(&v, "app", []string{"dev"}, client)
+	if err := m.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var missing MissingParameters
+	if err := m.Select("dev"); !errors.As(err, &missing) || missing[0] != "/app/dev/Foo" {
+		t.Fatalf("expected missing /app/dev/Foo, got %v", err)
+	}
+}
+
+func TestMultiEnvRequestRootBase(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/prod/Foo": "prod-foo",
+		"/dev/Foo":  "dev-foo",
+	}}
+	var v hasTags
+	m := NewMultiEnvRequest(&v, "/", []string{"prod", "dev"}, client)
+	if err := m.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Select("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "prod-foo" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+}
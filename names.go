@@ -0,0 +1,138 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// normalizeName returns name with a single leading slash and no trailing
+// slash, which is how names are matched regardless of how SSM returns them.
+// An ARN is returned without the leading slash, as SSM expects it.
+func normalizeName(name string) string {
+	name = strings.Trim(name, "/")
+	if isARN(name) {
+		return name
+	}
+	return "/" + name
+}
+
+// joinName returns the name of suffix under path, or suffix itself if it's
+// an ARN.
+func joinName(path, suffix string) string {
+	if suffix := strings.Trim(suffix, "/"); isARN(suffix) {
+		return suffix
+	}
+	return normalizeName(strings.Trim(path, "/") + "/" + strings.Trim(suffix, "/"))
+}
+
+// prefixed puts the prefix of WithNamePrefix in front of the path or name,
+// unless it's an ARN.
+func (c *requestConfig) prefixed(name string) string {
+	if c.namePrefix == "" || isARN(name) {
+		return name
+	}
+	return joinName(c.namePrefix, name)
+}
+
+// suffixed appends the suffix of WithNameSuffix to the name, unless it's an
+// ARN.
+func (c *requestConfig) suffixed(name string) string {
+	if isARN(name) {
+		return name
+	}
+	return name + c.nameSuffix
+}
+
+// fieldName returns the name of the parameter a field tagged t is bound to
+// under path: that of its suffix, or the full name given by its path
+// modifier, as in `ssm:"Region,path=/common/region"`, which gets the prefix
+// of WithNamePrefix, then the element of that subtree the field takes if
+// it's tagged position. positions tracks the elements taken so far.
+func (c *requestConfig) fieldName(path string, t fieldTag, positions map[string]int) (string, error) {
+	name := joinName(path, t.suffix)
+	if override, ok := t.modifiers["path"]; ok {
+		if name = normalizeName(override); name == "/" {
+			return "", errors.New("empty path")
+		}
+		name = c.prefixed(name)
+	}
+	if t.has("position") {
+		return positioned(positions, name, t)
+	}
+	return name, nil
+}
+
+// mirrored applies both WithNamePrefix and WithNameSuffix to the full name
+// of a parameter.
+func (c *requestConfig) mirrored(name string) string {
+	return c.suffixed(c.prefixed(name))
+}
+
+const (
+	maxNameLength     = 1011
+	maxHierarchyDepth = 15
+)
+
+// NormalizeName joins base and suffix the way NewRequest derives a field's
+// parameter name from its path and tag, and checks the result against the
+// SSM naming rules. An ARN is returned as it is, since its parameter may
+// belong to another account.
+func NormalizeName(base, suffix string) (string, error) {
+	name := joinName(base, suffix)
+	if isARN(name) {
+		return name, nil
+	}
+	if name == "/" {
+		return "", fmt.Errorf("invalid ssm parameter name %q: empty", name)
+	}
+	if len(name) > maxNameLength {
+		return "", fmt.Errorf("invalid ssm parameter name %q: longer than %d characters", name, maxNameLength)
+	}
+	segments := strings.Split(name[1:], "/")
+	if len(segments) > maxHierarchyDepth {
+		return "", fmt.Errorf("invalid ssm parameter name %q: deeper than %d levels", name, maxHierarchyDepth)
+	}
+	for _, segment := range segments {
+		if segment == "" {
+			return "", fmt.Errorf("invalid ssm parameter name %q: empty segment", name)
+		}
+		for _, c := range segment {
+			if !isNameRune(c) {
+				return "", fmt.Errorf("invalid ssm parameter name %q: invalid character %q", name, c)
+			}
+		}
+	}
+	if first := strings.ToLower(segments[0]); strings.HasPrefix(first, "aws") || strings.HasPrefix(first, "ssm") {
+		return "", fmt.Errorf("invalid ssm parameter name %q: reserved prefix", name)
+	}
+	return name, nil
+}
+
+func isNameRune(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-'
+}
+
+// underPath reports whether fetching path by GetParametersByPath returns
+// the parameter name.
+func underPath(path, name string, recursive bool) bool {
+	rest := strings.TrimPrefix(name, strings.TrimSuffix(path, "/")+"/")
+	if rest == name || rest == "" {
+		return false
+	}
+	return recursive || !strings.Contains(rest, "/")
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	for _, tc := range []struct{ base, suffix, expected string }{
+		{"/app", "db/host", "/app/db/host"},
+		{"app/", "/db/host/", "/app/db/host"},
+		{"/", "Foo", "/Foo"},
+		{"/app/token", "", "/app/token"},
+		{"/app", "arn:aws:ssm:us-east-1:123456789012:parameter/shared/db", "arn:aws:ssm:us-east-1:123456789012:parameter/shared/db"},
+		{"arn:aws:ssm:us-east-1:123456789012:parameter/shared", "db", "arn:aws:ssm:us-east-1:123456789012:parameter/shared/db"},
+	} {
+		name, err := NormalizeName(tc.base, tc.suffix)
+		if err != nil || name != tc.expected {
+			t.Errorf("NormalizeName(%q, %q) = %q, %v; expected %q", tc.base, tc.suffix, name, err, tc.expected)
+		}
+	}
+
+	for _, tc := range []struct{ base, suffix string }{
+		{"/", ""},
+		{"/app", "db//host"},
+		{"/app", "db host"},
+		{"/aws/app", "host"},
+		{"/SSM-app", "host"},
+		{"/app", strings.Repeat("a", maxNameLength)},
+		{"/app", strings.Repeat("a/", maxHierarchyDepth)},
+	} {
+		if name, err := NormalizeName(tc.base, tc.suffix); err == nil {
+			t.Errorf("NormalizeName(%q, %q) = %q; expected error", tc.base, tc.suffix, name)
+		}
+	}
+}
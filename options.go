@@ -0,0 +1,580 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// Option configures optional behavior of a Request or snapshot.
+type Option func(*requestConfig)
+
+type requestConfig struct {
+	recoverSetters bool
+	redactSecure   bool
+	timeout        time.Duration
+
+	missingRetries    int
+	missingRetryDelay time.Duration
+
+	decoders map[string]func(value string, target interface{}) error
+
+	interfaceFactories map[string]func(discriminator string) (interface{}, error)
+
+	afterSend []func(configurable interface{}) error
+
+	parallelPaths int
+
+	pathFunc func(ctx context.Context) (string, error)
+
+	skipZeroOnWrite bool
+	deleteOrphans   bool
+
+	contextCache bool
+
+	timing bool
+
+	conditions []condition
+
+	jsonTagFallback bool
+
+	fillIndexGaps bool
+
+	pageObservers []func(page *ssm.GetParametersByPathOutput)
+
+	nameAllowlist    []string
+	rejectDisallowed bool
+
+	throttleRetries int
+	throttleDelay   time.Duration
+
+	splitDecryption bool
+	noDecryption    bool
+	strictSecure    bool
+
+	applyLock     sync.Locker
+	envOmitSecure bool
+
+	maxListElements int
+
+	docs bool
+
+	valueCase Case
+
+	environment string
+
+	deprecationHook func(name, message string)
+
+	sources map[string]SourceFunc
+
+	tracer Tracer
+
+	fetchStrategy FetchStrategy
+
+	isolateSecure bool
+	secureTimeout time.Duration
+
+	jsonBundle string
+
+	budget time.Duration
+
+	tagVariables map[string]string
+
+	namePrefix string
+	nameSuffix string
+
+	validateRawJSON bool
+
+	missingPathAsEmpty bool
+
+	redactPolicy RedactPolicy
+}
+
+type condition struct {
+	fieldPath string
+	required  func(configurable interface{}) bool
+}
+
+func newRequestConfig(opts []Option) requestConfig {
+	c := requestConfig{
+		throttleRetries: defaultThrottleRetries,
+		throttleDelay:   defaultThrottleDelay,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// WithRecoverSetters makes Send recover from a panic while applying a
+// parameter, reporting it as a ParseError for that parameter and continuing
+// with the rest. By default such panics propagate.
+func WithRecoverSetters() Option {
+	return func(c *requestConfig) {
+		c.recoverSetters = true
+	}
+}
+
+// WithRedactSecureStrings omits the values of SecureString parameters from
+// snapshots taken with SnapshotPath.
+func WithRedactSecureStrings() Option {
+	return func(c *requestConfig) {
+		c.redactSecure = true
+	}
+}
+
+// WithRequestTimeout bounds the whole of Send, including every page fetched.
+// A tighter deadline on the context passed to Send still takes precedence.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(c *requestConfig) {
+		c.timeout = timeout
+	}
+}
+
+// WithMissingRetry makes Send fetch the parameters again, up to attempts more
+// times with delay between each, while required parameters are missing. This
+// papers over the eventual consistency of freshly written parameters. Other
+// errors are returned without retrying.
+func WithMissingRetry(attempts int, delay time.Duration) Option {
+	return func(c *requestConfig) {
+		c.missingRetries = attempts
+		c.missingRetryDelay = delay
+	}
+}
+
+// WithDecoder registers decode for fields whose ssm tag carries modifier,
+// e.g. `ssm:"Name,modifier"`. decode receives a pointer to the field, and
+// its error is reported as a ParseError for the parameter. A field can't
+// carry the modifiers of two decoders.
+func WithDecoder(modifier string, decode func(value string, target interface{}) error) Option {
+	return func(c *requestConfig) {
+		if c.decoders == nil {
+			c.decoders = make(map[string]func(string, interface{}) error)
+		}
+		c.decoders[modifier] = decode
+	}
+}
+
+// WithInterfaceFactory binds the interface-typed field at fieldPath (its Go
+// field name, with nested fields joined by ".") to a parameter subtree. The
+// tag names the subtree root, and the discriminator parameter beneath it
+// ("type" unless overridden with `discriminator=name`) is passed to factory
+// to choose the concrete value. When factory returns a pointer to a struct,
+// the rest of the subtree is applied to it through its own ssm tags.
+func WithInterfaceFactory(fieldPath string, factory func(discriminator string) (interface{}, error)) Option {
+	return func(c *requestConfig) {
+		if c.interfaceFactories == nil {
+			c.interfaceFactories = make(map[string]func(string) (interface{}, error))
+		}
+		c.interfaceFactories[fieldPath] = factory
+	}
+}
+
+// WithAfterSend registers fn to run once Send has applied every parameter
+// successfully, e.g. to compute derived fields. fn receives the configurable
+// passed to NewRequest, and its error is returned by Send. Multiple
+// callbacks run in the order given.
+func WithAfterSend(fn func(configurable interface{}) error) Option {
+	return func(c *requestConfig) {
+		c.afterSend = append(c.afterSend, fn)
+	}
+}
+
+// WithParallelPaths lets a Request built by MergeRequests send up to n of its
+// requests concurrently. Setters still never run concurrently with one
+// another, so requests may share a configurable.
+func WithParallelPaths(n int) Option {
+	return func(c *requestConfig) {
+		c.parallelPaths = n
+	}
+}
+
+// WithPathFunc makes Send call fn to resolve the base path, in place of the
+// one given when the Request was built, and bind fields relative to it. Until
+// then ParameterNames reports names under the original path.
+func WithPathFunc(fn func(ctx context.Context) (string, error)) Option {
+	return func(c *requestConfig) {
+		c.pathFunc = fn
+	}
+}
+
+// WithSkipZeroOnWrite makes WriteConfig leave alone the parameters of
+// optional fields that hold their zero value, rather than overwriting them.
+// Required fields are always written.
+func WithSkipZeroOnWrite() Option {
+	return func(c *requestConfig) {
+		c.skipZeroOnWrite = true
+	}
+}
+
+// WithDeleteOrphans makes SyncConfig delete the parameters under its path
+// that no field is bound to. Without it they are only reported.
+func WithDeleteOrphans() Option {
+	return func(c *requestConfig) {
+		c.deleteOrphans = true
+	}
+}
+
+// WithTiming makes Send record the time spent on each page and overall, for
+// Timings to report.
+func WithTiming() Option {
+	return func(c *requestConfig) {
+		c.timing = true
+	}
+}
+
+// WithConditionalRequired decides whether the field at fieldPath is required
+// by calling required once Send has applied every parameter it fetched, so
+// required can inspect the values of other fields. This overrides the
+// field's optional modifier either way.
+func WithConditionalRequired(fieldPath string, required func(configurable interface{}) bool) Option {
+	return func(c *requestConfig) {
+		c.conditions = append(c.conditions, condition{fieldPath: fieldPath, required: required})
+	}
+}
+
+// WithJSONTagFallback binds fields that have a json tag but no ssm tag as if
+// they were tagged `ssm:"<json name>"`. An ssm tag always takes precedence.
+func WithJSONTagFallback() Option {
+	return func(c *requestConfig) {
+		c.jsonTagFallback = true
+	}
+}
+
+// WithFillIndexGaps makes Send fill the elements missing from a field tagged
+// `indexed` with empty strings. By default a gap in the indices is a
+// ParseError for the field.
+func WithFillIndexGaps() Option {
+	return func(c *requestConfig) {
+		c.fillIndexGaps = true
+	}
+}
+
+// WithRawPageObserver calls observe with every page GetParametersByPath
+// returns to Send, before any of it is applied. It's meant for debugging:
+// pages hold decrypted SecureString values unless decryption is disabled,
+// so take care not to log or retain them. observe must not modify the page.
+func WithRawPageObserver(observe func(page *ssm.GetParametersByPathOutput)) Option {
+	return func(c *requestConfig) {
+		c.pageObservers = append(c.pageObservers, observe)
+	}
+}
+
+// WithNameAllowlist makes Send ignore every fetched parameter whose name
+// matches none of patterns, as a guard against a mistaken path pulling in
+// another team's parameters. Patterns are matched against the full name as
+// by path.Match, so * doesn't match across a /. An empty allowlist allows
+// no name.
+func WithNameAllowlist(patterns []string) Option {
+	return func(c *requestConfig) {
+		c.nameAllowlist = append(c.nameAllowlist, patterns...)
+		if c.nameAllowlist == nil {
+			c.nameAllowlist = []string{}
+		}
+	}
+}
+
+// WithRejectDisallowedNames makes Send fail with an error wrapping
+// ErrNameNotAllowed, rather than ignore, a parameter outside the allowlist
+// given to WithNameAllowlist.
+func WithRejectDisallowedNames() Option {
+	return func(c *requestConfig) {
+		c.rejectDisallowed = true
+	}
+}
+
+// WithThrottleRetry sets how many times Send retries fetching a page that
+// SSM throttled, and the longest delay before the first retry. Each retry
+// waits a random time up to twice the previous limit. By default Send
+// retries 3 times after up to 100ms; retries of 0 disables this.
+func WithThrottleRetry(retries int, delay time.Duration) Option {
+	return func(c *requestConfig) {
+		c.throttleRetries = retries
+		c.throttleDelay = delay
+	}
+}
+
+// WithSplitDecryption makes Send decrypt only the fields tagged secure, at
+// the cost of fetching the path twice: once without decryption for the other
+// fields, then once with it for the secure ones. This lets a role that can
+// only decrypt some of the KMS keys under the path load what it can. A field
+// not tagged secure whose parameter is a SecureString gets a ParseError.
+func WithSplitDecryption() Option {
+	return func(c *requestConfig) {
+		c.splitDecryption = true
+	}
+}
+
+// WithoutDecryption makes Send fetch without decryption, which for a path
+// of plaintext parameters saves the latency of KMS and the kms:Decrypt
+// permission. A parameter that turns out to be a SecureString gets a
+// ParseError rather than setting its field to the ciphertext.
+func WithoutDecryption() Option {
+	return func(c *requestConfig) {
+		c.noDecryption = true
+	}
+}
+
+// WithStrictSecure makes Send report a ParseError, rather than apply the
+// value, for a field tagged secure whose parameter is not a SecureString,
+// since that's a secret stored in plaintext.
+func WithStrictSecure() Option {
+	return func(c *requestConfig) {
+		c.strictSecure = true
+	}
+}
+
+// WithApplyLock makes Send hold lock while it sets fields, so that readers
+// holding it too never see a partly applied refresh. Send fetches every page
+// before taking lock, then applies them all at once.
+func WithApplyLock(lock sync.Locker) Option {
+	return func(c *requestConfig) {
+		c.applyLock = lock
+	}
+}
+
+// WithEnvOmitSecure makes WriteEnv leave out the fields tagged secure and any
+// parameter fetched as a SecureString. WithRedactPolicy(RedactAll) leaves
+// out every one.
+func WithEnvOmitSecure() Option {
+	return func(c *requestConfig) {
+		c.envOmitSecure = true
+	}
+}
+
+// WithMaxListElements makes Send report a ParseError, rather than allocate
+// the slice, for a []string field whose StringList parameter has more than
+// n elements, or tagged `indexed` with an index of n or more. By default
+// there's no limit, except that indexed lists stop at 100000 elements.
+func WithMaxListElements(n int) Option {
+	return func(c *requestConfig) {
+		c.maxListElements = n
+	}
+}
+
+// Case is a case folding applied to string values by WithValueCaseFold.
+type Case int
+
+const (
+	CaseNone Case = iota
+	CaseLower
+	CaseUpper
+)
+
+// WithValueCaseFold makes Send fold the case of the values it sets string
+// and []string fields to, after any trimPrefix or trimSuffix. Decoders and
+// Setters get the value as-is. A field's case modifier, one of lower, upper
+// or none, takes precedence.
+func WithValueCaseFold(fold Case) Option {
+	return func(c *requestConfig) {
+		c.valueCase = fold
+	}
+}
+
+// WithEnvironment names the environment Send runs in, such as "prod", which
+// makes the fields tagged requiredIn with it required and those tagged
+// requiredIn without it optional.
+func WithEnvironment(environment string) Option {
+	return func(c *requestConfig) {
+		c.environment = environment
+	}
+}
+
+// WithDeprecationHook makes Send call hook for each parameter it applies to
+// a field tagged deprecated, as in `ssm:"OldKey,deprecated=use NewKey"`,
+// with the name of the parameter and the message. Quote a message that has
+// commas, as for a default.
+func WithDeprecationHook(hook func(name, message string)) Option {
+	return func(c *requestConfig) {
+		c.deprecationHook = hook
+	}
+}
+
+// SourceFunc fetches the value of a field from outside SSM, as given to
+// WithSource. It returns found false, rather than an error, if there's no
+// such value, which Send then reports like a missing parameter.
+type SourceFunc func(ctx context.Context, id string) (value string, found bool, err error)
+
+// WithSource makes Send fetch the fields tagged with modifier by calling
+// fetch rather than from SSM, which lets a subpackage such as
+// ssmsecretsmanager add a service without the root package depending on
+// it. fetch gets the value of the modifier as id, as in
+// `ssm:"DbPassword,secretsmanager=prod/db"`, or else the name the field
+// would have in SSM, and is called once per field after the fetch from SSM.
+func WithSource(modifier string, fetch SourceFunc) Option {
+	return func(c *requestConfig) {
+		if c.sources == nil {
+			c.sources = make(map[string]SourceFunc)
+		}
+		c.sources[modifier] = fetch
+	}
+}
+
+// WithTracer makes Send report itself and each page it fetches by path to
+// tracer, which lets a subpackage such as ssmotel add tracing without the
+// root package depending on it.
+func WithTracer(tracer Tracer) Option {
+	return func(c *requestConfig) {
+		c.tracer = tracer
+	}
+}
+
+// FetchStrategy is how Send fetches the parameters under the base path, for
+// WithFetchStrategy.
+type FetchStrategy int
+
+const (
+	// FetchByPath fetches them with GetParametersByPath.
+	FetchByPath FetchStrategy = iota
+	// FetchByName fetches each name bound to a field with GetParameters, or
+	// GetParameter if the client has no GetParameters.
+	FetchByName
+	// FetchAuto fetches by name when the client has GetParameters and no
+	// more than 10 names are bound, which it fetches in one call, and by
+	// path otherwise.
+	FetchAuto
+)
+
+// WithFetchStrategy chooses how Send fetches the parameters under the base
+// path; the default is FetchByPath. Either way Send reports the same missing
+// parameters and parse errors, so the choice is only about the number of
+// calls: a fetch by path also returns the parameters no field is bound to.
+// FetchByName makes NewRequest fail for a struct that needs a fetch by path,
+// with interface, indexed or map fields, names below a path not fetched
+// recursively, or the ParameterFilters of NewRequestWithInput; FetchAuto
+// fetches such a struct by path.
+func WithFetchStrategy(strategy FetchStrategy) Option {
+	return func(c *requestConfig) {
+		c.fetchStrategy = strategy
+	}
+}
+
+// WithIsolateSecureFetches is like WithSplitDecryption, but instead of the
+// second fetch by path gets each field tagged secure with a GetParameter
+// call of its own, all at once, so that a slow KMS key holds up only its own
+// field. Each call times out after timeout, unless it's 0, and Send returns
+// the first error in name order once the others are applied. It panics
+// unless the client implements GetParameterAPIClient. WithTiming records
+// each call in Timings.Secure.
+func WithIsolateSecureFetches(timeout time.Duration) Option {
+	return func(c *requestConfig) {
+		c.splitDecryption = true
+		c.isolateSecure = true
+		c.secureTimeout = timeout
+	}
+}
+
+// WithJSONBundle makes Send fetch the single parameter fullName in place of
+// the fetch by path. It holds a JSON object whose keys are names relative
+// to the base path, as in {"db/host": "db.internal", "db/port": 5432}, and
+// each value is applied as the parameter of that name would be: a string as
+// is, anything else as its JSON text. A key no field is bound to is ignored,
+// and a field whose key is absent is missing. Fields fetched by name are
+// fetched as usual.
+func WithJSONBundle(fullName string) Option {
+	return func(c *requestConfig) {
+		c.jsonBundle = normalizeName(fullName)
+	}
+}
+
+// WithTotalBudget caps the whole of Send at budget, however the retries of
+// WithMissingRetry and WithThrottleRetry and the pages add up, returning a
+// *BudgetExceededError once it runs out. Unlike WithRequestTimeout, Send
+// gives up as soon as the delay before a retry would overrun the budget,
+// rather than waiting for the deadline.
+func WithTotalBudget(budget time.Duration) Option {
+	return func(c *requestConfig) {
+		c.budget = budget
+	}
+}
+
+// WithTagVariables makes NewRequest expand each ${VAR} in the base path,
+// the names in ssm tags and their path modifiers to the value of VAR in vars,
+// as in `ssm:"${ENV}/db/host"`. A variable missing from vars is an error.
+func WithTagVariables(vars map[string]string) Option {
+	return func(c *requestConfig) {
+		c.tagVariables = vars
+	}
+}
+
+// WithNamePrefix points a Request at a mirror of its parameters under
+// prefix, as for a blue/green rollout: NewRequest puts prefix in front of the
+// base path and of every full name, such as those of path modifiers and
+// AddParameter, so "/app/db/host" is fetched as "/green/app/db/host". The
+// names reported by Missing, Resolved and the errors of Send are those
+// fetched. ARNs are left as they are.
+func WithNamePrefix(prefix string) Option {
+	return func(c *requestConfig) {
+		c.namePrefix = normalizeName(prefix)
+	}
+}
+
+// WithNameSuffix appends suffix to the name of every parameter bound to a
+// single field or added by AddParameter, so "/app/db/host" is fetched as
+// "/app/db/host-green", while the base path and the subtrees of interface,
+// indexed and map fields keep their names. ARNs are left as they are.
+func WithNameSuffix(suffix string) Option {
+	return func(c *requestConfig) {
+		c.nameSuffix = suffix
+	}
+}
+
+// WithRawJSONValidation makes Send reject, as a ParseError, a value that
+// isn't valid JSON for a json.RawMessage field or element of a map field,
+// which otherwise keeps the value as it is without unmarshaling it.
+func WithRawJSONValidation() Option {
+	return func(c *requestConfig) {
+		c.validateRawJSON = true
+	}
+}
+
+// WithTreatMissingPathAsEmpty makes Send read a ValidationException or
+// ParameterNotFound error from the first GetParametersByPath call, which SSM
+// may return when the path doesn't exist at all, as an empty page. Fields
+// are then reported missing or given their defaults as for a path with no
+// parameters, so a config of optional fields loads under an absent path.
+func WithTreatMissingPathAsEmpty() Option {
+	return func(c *requestConfig) {
+		c.missingPathAsEmpty = true
+	}
+}
+
+// RedactPolicy controls which values ResolvedValues redacts.
+type RedactPolicy int
+
+const (
+	// RedactSecure redacts the values of SecureString parameters and of
+	// fields tagged secure. It's the default.
+	RedactSecure RedactPolicy = iota
+	// RedactNone redacts nothing, for debugging.
+	RedactNone
+	// RedactAll redacts every value, leaving only the names.
+	RedactAll
+)
+
+// WithRedactPolicy sets which values ResolvedValues replaces with
+// RedactedValue. WriteEnv and SnapshotPath, whose output is read back
+// rather than displayed, keep to WithEnvOmitSecure and
+// WithRedactSecureStrings, but for RedactAll: WriteEnv then writes nothing
+// and SnapshotPath redacts every parameter.
+func WithRedactPolicy(policy RedactPolicy) Option {
+	return func(c *requestConfig) {
+		c.redactPolicy = policy
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestNewRequestConfig(t *testing.T) {
+	want := requestConfig{
+		throttleRetries: defaultThrottleRetries,
+		throttleDelay:   defaultThrottleDelay,
+	}
+	if got := newRequestConfig(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v with no options, want %+v", got, want)
+	}
+
+	got := newRequestConfig([]Option{WithThrottleRetry(1, 0), WithThrottleRetry(0, 0), WithRecoverSetters()})
+	if got.throttleRetries != 0 || !got.recoverSetters {
+		t.Errorf("got %+v, want later options to win", got)
+	}
+}
+
+func TestNewRequestWithoutOptions(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{"/HasTags/Foo": "foo"}}
+	var v hasTags
+	if err := NewRequest(&v, "/HasTags", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo" {
+		t.Errorf("got Foo %q, want foo", v.Foo)
+	}
+}
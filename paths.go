@@ -0,0 +1,85 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// RequestPath is a path or name Send fetches, as listed by Paths.
+type RequestPath struct {
+	// Path is a base path when Strategy is FetchByPath, and the full name
+	// or ARN of a parameter when it's FetchByName.
+	Path     string
+	Strategy FetchStrategy
+	// Recursive reports whether a base path is fetched with the parameters
+	// below its children, which an IAM policy grants as Path/*.
+	Recursive bool
+}
+
+// Paths lists the base path the last bind resolved, which for a Request
+// built with WithPathFunc is that of the last Send. A Request without a
+// client fetches nothing.
+func (r *request) Paths() []RequestPath {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.client == nil {
+		return nil
+	}
+	var paths []RequestPath
+	if r.needsPathFetch() {
+		if r.config.jsonBundle != "" {
+			paths = append(paths, RequestPath{Path: r.config.jsonBundle, Strategy: FetchByName})
+		} else {
+			paths = append(paths, RequestPath{
+				Path:      normalizeName(aws.ToString(r.input.Path)),
+				Strategy:  FetchByPath,
+				Recursive: aws.ToBool(r.input.Recursive),
+			})
+		}
+	}
+	for name := range r.byName {
+		paths = append(paths, RequestPath{Path: name, Strategy: FetchByName})
+	}
+	sortPaths(paths)
+	return paths
+}
+
+// Paths lists the distinct paths of every request.
+func (m *mergedRequest) Paths() []RequestPath {
+	seen := make(map[RequestPath]bool)
+	var paths []RequestPath
+	for _, req := range m.requests {
+		for _, p := range req.Paths() {
+			if !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+	sortPaths(paths)
+	return paths
+}
+
+func sortPaths(paths []RequestPath) {
+	sort.Slice(paths, func(i, j int) bool {
+		if paths[i].Path != paths[j].Path {
+			return paths[i].Path < paths[j].Path
+		}
+		return paths[i].Strategy < paths[j].Strategy
+	})
+}
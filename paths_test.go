@@ -0,0 +1,61 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPaths(t *testing.T) {
+	type config struct {
+		Foo    string `ssm:"Foo"`
+		Region string `ssm:"Region,path=/common/region"`
+	}
+	client := &batchingClient{}
+	req := NewRequest(&config{}, "/app", client)
+	req.AddParameter("/shared/flag", func(string) {})
+	expected := []RequestPath{
+		{Path: "/app", Strategy: FetchByPath},
+		{Path: "/common/region", Strategy: FetchByName},
+		{Path: "/shared/flag", Strategy: FetchByName},
+	}
+	if paths := req.Paths(); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("got %+v, want %+v", paths, expected)
+	}
+
+	var indexed struct {
+		Admins []string `ssm:"admins,indexed"`
+	}
+	merged := MergeRequests([]Request{
+		NewRequest(&config{}, "/app", client),
+		NewRequest(&indexed, "/team", client),
+		NewRequest(&hasTags{}, "/small", client, WithFetchStrategy(FetchByName)),
+	})
+	expected = []RequestPath{
+		{Path: "/app", Strategy: FetchByPath},
+		{Path: "/common/region", Strategy: FetchByName},
+		{Path: "/small/Foo", Strategy: FetchByName},
+		{Path: "/small/OptionalBar", Strategy: FetchByName},
+		{Path: "/team", Strategy: FetchByPath, Recursive: true},
+	}
+	if paths := merged.Paths(); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("got %+v, want %+v", paths, expected)
+	}
+
+	if paths := NewRequest(&config{}, "/app", nil).Paths(); paths != nil {
+		t.Errorf("expected no paths without a client, got %+v", paths)
+	}
+}
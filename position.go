@@ -0,0 +1,43 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// positioned returns the name of the element of the indexed subtree name a
+// field tagged position is bound to, counting in positions the elements
+// taken so far. Fields tagged position with the same name take its elements
+// in declaration order, so that
+//
+//	Host string `ssm:"args,position"`
+//	Port int    `ssm:"args,position"`
+//
+// bind Host to args/0 and Port to args/1. A field tagged position=N takes
+// element N, and the next one tagged just position element N+1.
+func positioned(positions map[string]int, name string, t fieldTag) (string, error) {
+	i := positions[name]
+	if value := t.modifiers["position"]; value != "" {
+		n, ok := parseIndex(value)
+		if !ok {
+			return "", fmt.Errorf("invalid position %q", value)
+		}
+		i = n
+	}
+	positions[name] = i + 1
+	return joinName(name, strconv.Itoa(i)), nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+func TestPosition(t *testing.T) {
+	var v struct {
+		Host    string `ssm:"args,position"`
+		Port    int    `ssm:"args,position"`
+		Verbose bool   `ssm:"args,position=3,optional"`
+		Extra   string `ssm:"args,position,optional"`
+		Region  string `ssm:"Region"`
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/args/0": "db.internal",
+		"/app/args/1": "5432",
+		"/app/args/2": "skipped",
+		"/app/args/3": "true",
+		"/app/Region": "us-west-2",
+	}}
+	req := NewRequestWithInput(&v, ssm.GetParametersByPathInput{Path: aws.String("/app"), Recursive: aws.Bool(true)}, client)
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Host != "db.internal" || v.Port != 5432 || !v.Verbose || v.Extra != "" || v.Region != "us-west-2" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+	expected := []string{"/app/Region", "/app/args/0", "/app/args/1", "/app/args/3", "/app/args/4"}
+	if names := req.ParameterNames(); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("unexpected parameter names: %v", names)
+	}
+}
+
+func TestPositionByName(t *testing.T) {
+	var v struct {
+		Host string `ssm:"args,position"`
+		Port int    `ssm:"args,position"`
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/args/0": "db.internal",
+		"/app/args/1": "5432",
+	}}
+	req := NewRequest(&v, "/app", client)
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Host != "db.internal" || v.Port != 5432 {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+	expected := []RequestPath{
+		{Path: "/app/args/0", Strategy: FetchByName},
+		{Path: "/app/args/1", Strategy: FetchByName},
+	}
+	if paths := req.Paths(); !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("unexpected paths: %v", paths)
+	}
+}
+
+func TestPositionInvalid(t *testing.T) {
+	var v struct {
+		Host string `ssm:"args,position=first"`
+	}
+	_, err := TryNewRequest(&v, "/app", &fakeClient{})
+	var fieldErrors FieldErrors
+	if !errors.As(err, &fieldErrors) || !strings.Contains(err.Error(), `invalid position "first"`) {
+		t.Fatalf("expected an invalid position, got %v", err)
+	}
+}
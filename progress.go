@@ -0,0 +1,124 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"sync"
+)
+
+// Progress reports how far SendWithProgress has got.
+type Progress struct {
+	// Pages counts the pages fetched by path so far.
+	Pages int
+	// Resolved counts the parameters found and applied so far.
+	Resolved int
+}
+
+// progressReporter sends the latest Progress of one or more requests. The
+// channel holds at most one Progress, which a newer one replaces, so that a
+// slow reader never holds up Send.
+type progressReporter struct {
+	lock     sync.Mutex
+	ch       chan Progress
+	pages    int
+	resolved map[*request]int
+}
+
+func newProgressReporter(requests []*request) *progressReporter {
+	p := &progressReporter{
+		ch:       make(chan Progress, 1),
+		resolved: make(map[*request]int, len(requests)),
+	}
+	for _, r := range requests {
+		r := r
+		r.lock.Lock()
+		r.progress = func(resolved int) {
+			p.lock.Lock()
+			defer p.lock.Unlock()
+			p.pages++
+			p.resolved[r] = resolved
+			var total int
+			for _, n := range p.resolved {
+				total += n
+			}
+			p.send(Progress{Pages: p.pages, Resolved: total})
+		}
+		r.lock.Unlock()
+	}
+	return p
+}
+
+// send must be called with p.lock held, which makes it the only sender.
+func (p *progressReporter) send(progress Progress) {
+	select {
+	case p.ch <- progress:
+	default:
+		select {
+		case <-p.ch:
+		default:
+		}
+		p.ch <- progress
+	}
+}
+
+// run sends req in the background, reporting progress until it's done. A
+// panic from Send is raised again by the returned func.
+func (p *progressReporter) run(ctx context.Context, req Request) (<-chan Progress, func() error) {
+	done := make(chan struct{})
+	var err error
+	var panicked interface{}
+	go func() {
+		defer close(done)
+		defer close(p.ch)
+		defer func() {
+			panicked = recover()
+		}()
+		err = req.Send(ctx)
+		p.lock.Lock()
+		p.send(Progress{Pages: p.pages, Resolved: len(req.Resolved())})
+		p.lock.Unlock()
+	}()
+	return p.ch, func() error {
+		<-done
+		if panicked != nil {
+			panic(panicked)
+		}
+		return err
+	}
+}
+
+func (r *request) SendWithProgress(ctx context.Context) (<-chan Progress, func() error) {
+	return newProgressReporter([]*request{r}).run(ctx, r)
+}
+
+func (m *mergedRequest) SendWithProgress(ctx context.Context) (<-chan Progress, func() error) {
+	return newProgressReporter(m.leaves()).run(ctx, m)
+}
+
+// leaves returns the requests built by NewRequest among those merged,
+// including those within other merged requests.
+func (m *mergedRequest) leaves() []*request {
+	var leaves []*request
+	for _, req := range m.requests {
+		switch req := req.(type) {
+		case *request:
+			leaves = append(leaves, req)
+		case *mergedRequest:
+			leaves = append(leaves, req.leaves()...)
+		}
+	}
+	return leaves
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSendWithProgress(t *testing.T) {
+	client := &fakeClient{
+		parameters: map[string]string{"/HasTags/Foo": "foo", "/HasTags/OptionalBar": "bar"},
+		pageSize:   1,
+	}
+	var v hasTags
+	progress, wait := NewRequest(&v, "/HasTags", client).SendWithProgress(context.Background())
+	var last Progress
+	for p := range progress {
+		if p.Pages < last.Pages || p.Resolved < last.Resolved {
+			t.Errorf("progress went backwards: %+v after %+v", p, last)
+		}
+		last = p
+	}
+	if err := wait(); err != nil {
+		t.Fatal(err)
+	}
+	if last != (Progress{Pages: 2, Resolved: 2}) || v.OptionalBar != "bar" {
+		t.Fatalf("unexpected final progress %+v, result %+v", last, v)
+	}
+}
+
+func TestMergedSendWithProgress(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{"/db/host": "db.internal", "/cache/url": "redis://cache"}}
+	var db struct {
+		Host string `ssm:"host"`
+	}
+	var cache struct {
+		URL string `ssm:"url"`
+	}
+	merged := MergeRequests([]Request{
+		NewRequest(&db, "/db", client),
+		MergeRequests([]Request{NewRequest(&cache, "/cache", client)}),
+	}, WithParallelPaths(2))
+	progress, wait := merged.SendWithProgress(context.Background())
+	var last Progress
+	for last = range progress {
+	}
+	if err := wait(); err != nil {
+		t.Fatal(err)
+	}
+	if last != (Progress{Pages: 2, Resolved: 2}) {
+		t.Fatalf("unexpected final progress %+v", last)
+	}
+}
+
+func TestSendWithProgressPanic(t *testing.T) {
+	var v hasTags
+	req := NewRequest(&v, "/HasTags", &fakeClient{parameters: map[string]string{"/HasTags/Foo": "foo"}})
+	injectPanic(req, "/HasTags/Foo")
+	progress, wait := req.SendWithProgress(context.Background())
+	for range progress {
+	}
+	expectPanic(t, func() { _ = wait() })
+}
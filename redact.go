@@ -0,0 +1,60 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+// RedactedValue replaces the values ResolvedValues redacts.
+const RedactedValue = "[redacted]"
+
+// redacts reports whether policy redacts a value fetched as a SecureString
+// or not.
+func (policy RedactPolicy) redacts(secure bool) bool {
+	switch policy {
+	case RedactNone:
+		return false
+	case RedactAll:
+		return true
+	}
+	return secure
+}
+
+func (r *request) ResolvedValues() map[string]string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	values := make(map[string]string, len(r.values))
+	for name, value := range r.values {
+		if _, ok := r.resolved[name]; !ok {
+			continue
+		}
+		_, tagged := r.secure[name]
+		_, fetched := r.secureStrings[name]
+		if r.config.redactPolicy.redacts(tagged || fetched) {
+			value = RedactedValue
+		}
+		values[name] = value
+	}
+	return values
+}
+
+// ResolvedValues redacts the values of each request as set by its own
+// WithRedactPolicy.
+func (m *mergedRequest) ResolvedValues() map[string]string {
+	values := make(map[string]string)
+	for _, req := range m.requests {
+		for name, value := range req.ResolvedValues() {
+			values[name] = value
+		}
+	}
+	return values
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestResolvedValues(t *testing.T) {
+	type config struct {
+		URL      string `ssm:"url"`
+		Password string `ssm:"password,secure"`
+		Token    string `ssm:"token"`
+		Absent   string `ssm:"absent,optional"`
+	}
+	client := &fakeClient{
+		parameters: map[string]string{
+			"/app/url":      "postgres://db",
+			"/app/password": "hunter2",
+			"/app/token":    "t0ken",
+		},
+		secure: map[string]bool{"/app/token": true},
+	}
+	for _, test := range []struct {
+		opts     []Option
+		expected map[string]string
+	}{
+		{nil, map[string]string{"/app/url": "postgres://db", "/app/password": RedactedValue, "/app/token": RedactedValue}},
+		{[]Option{WithRedactPolicy(RedactNone)}, map[string]string{"/app/url": "postgres://db", "/app/password": "hunter2", "/app/token": "t0ken"}},
+		{[]Option{WithRedactPolicy(RedactAll)}, map[string]string{"/app/url": RedactedValue, "/app/password": RedactedValue, "/app/token": RedactedValue}},
+	} {
+		var v config
+		req := NewRequest(&v, "/app", client, test.opts...)
+		if values := req.ResolvedValues(); len(values) != 0 {
+			t.Fatalf("unexpected values before Send: %v", values)
+		}
+		if err := req.Send(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if values := req.ResolvedValues(); !reflect.DeepEqual(values, test.expected) {
+			t.Errorf("got %v, want %v", values, test.expected)
+		}
+		if v.Password != "hunter2" || v.Token != "t0ken" {
+			t.Errorf("expected the fields to be set as fetched: %+v", v)
+		}
+	}
+}
+
+func TestMergedResolvedValues(t *testing.T) {
+	client := &fakeClient{
+		parameters: map[string]string{"/db/password": "hunter2", "/cache/url": "redis://cache"},
+		secure:     map[string]bool{"/db/password": true},
+	}
+	var db struct {
+		Password string `ssm:"password"`
+	}
+	var cache struct {
+		URL string `ssm:"url"`
+	}
+	req := MergeRequests([]Request{
+		NewRequest(&db, "/db", client),
+		NewRequest(&cache, "/cache", client, WithRedactPolicy(RedactAll)),
+	})
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"/db/password": RedactedValue, "/cache/url": RedactedValue}
+	if values := req.ResolvedValues(); !reflect.DeepEqual(values, expected) {
+		t.Fatalf("got %v, want %v", values, expected)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// RefreshAPIClient is the subset of *ssm.Client used by a Refresher.
+type RefreshAPIClient interface {
+	PathFetcher
+	GetParametersAPIClient
+	ssm.DescribeParametersAPIClient
+}
+
+var _ RefreshAPIClient = (*ssm.Client)(nil)
+
+// Refresher keeps configurable up to date with its parameters, re-reading
+// only those whose version changed since the last Refresh. Unchanged fields
+// keep their current values, which saves decrypting every SecureString on
+// each of frequent refreshes.
+type Refresher struct {
+	configurable interface{}
+	path         string
+	client       RefreshAPIClient
+	opts         []Option
+
+	lock sync.Mutex
+	// versions holds the version of each bound parameter as of the last
+	// Refresh, or nil until one succeeds.
+	versions map[string]int64
+}
+
+// NewRefresher returns a Refresher for configurable, which Refresh loads as
+// a Request built by NewRequest with the same arguments would.
+func NewRefresher(configurable interface{}, path string, client RefreshAPIClient, opts ...Option) *Refresher {
+	return &Refresher{configurable: configurable, path: path, client: client, opts: opts}
+}
+
+// Refresh lists the versions of the parameters with DescribeParameters, one
+// call per 50 of them, then fetches those that changed or appeared with
+// GetParameters, one call per 10. The first Refresh, and any after one
+// failed, instead sends a Request for every parameter, as does one after a
+// parameter was deleted, so that defaults and missing parameters are
+// handled as by Send. So does every Refresh of a struct with interface,
+// indexed or map fields, or a Request built with WithPathFunc or
+// WithJSONBundle.
+func (rf *Refresher) Refresh(ctx context.Context) error {
+	rf.lock.Lock()
+	defer rf.lock.Unlock()
+
+	req, err := TryNewRequest(rf.configurable, rf.path, rf.client, rf.opts...)
+	if err != nil {
+		return err
+	}
+	r := req.(*request)
+	if rf.versions == nil || r.collectsUnmatched() || r.config.pathFunc != nil || r.config.jsonBundle != "" {
+		return rf.send(ctx, r)
+	}
+
+	current, err := r.describeVersions(ctx, rf.client)
+	if err != nil {
+		return err
+	}
+	var changed []string
+	for name := range r.setters {
+		version, ok := current[name]
+		if _, known := rf.versions[name]; known && !ok && !isARN(name) {
+			return rf.send(ctx, r)
+		}
+		if ok && version != rf.versions[name] || isARN(name) {
+			changed = append(changed, name)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	sort.Strings(changed)
+	return rf.update(ctx, r, changed)
+}
+
+// update fetches the parameters named by changed and applies them to the
+// fields already loaded, recording their versions.
+func (rf *Refresher) update(ctx context.Context, r *request, changed []string) error {
+	parameters, err := r.getParameters(ctx, rf.client, changed)
+	if err != nil {
+		return err
+	}
+	r.done = true
+	r.fetched = make(map[string]struct{})
+	r.values = make(map[string]string)
+	r.secureStrings = make(map[string]struct{})
+	r.versions = make(map[string]int64)
+	r.lockApply()
+	parseErrors, err := r.applyPage(parameters, nil, nil, nil)
+	r.unlockApply()
+	if err != nil {
+		return err
+	}
+	if len(parseErrors) > 0 {
+		sort.SliceStable(parseErrors, func(i, j int) bool {
+			return parseErrors[i].Name < parseErrors[j].Name
+		})
+		return parseErrors
+	}
+	for name, version := range r.versions {
+		rf.versions[name] = version
+	}
+	return nil
+}
+
+func (rf *Refresher) send(ctx context.Context, r *request) error {
+	rf.versions = nil
+	if err := r.Send(ctx); err != nil {
+		return err
+	}
+	rf.versions = make(map[string]int64, len(r.setters))
+	for name := range r.setters {
+		if version, ok := r.versions[name]; ok {
+			rf.versions[name] = version
+		}
+	}
+	return nil
+}
+
+// describeVersions returns the current version of each parameter under the
+// path of r and of those it fetches by name, but for ARNs, which
+// DescribeParameters can't list.
+func (r *request) describeVersions(ctx context.Context, client ssm.DescribeParametersAPIClient) (map[string]int64, error) {
+	versions := make(map[string]int64)
+	found := func(metadata types.ParameterMetadata) {
+		versions[normalizeName(aws.ToString(metadata.Name))] = metadata.Version
+	}
+
+	if r.needsPathFetch() {
+		option := "OneLevel"
+		if aws.ToBool(r.input.Recursive) {
+			option = "Recursive"
+		}
+		paginator := ssm.NewDescribeParametersPaginator(client, &ssm.DescribeParametersInput{
+			MaxResults: aws.Int32(describeBatchSize),
+			ParameterFilters: []types.ParameterStringFilter{{
+				Key:    aws.String("Path"),
+				Option: aws.String(option),
+				Values: []string{normalizeName(aws.ToString(r.input.Path))},
+			}},
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, metadata := range page.Parameters {
+				found(metadata)
+			}
+		}
+	}
+
+	var names []string
+	for name := range r.byName {
+		if !isARN(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if err := describeNames(ctx, client, names, found); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
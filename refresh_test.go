@@ -0,0 +1,108 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// versionedClient is a fakeClient that reports a version for each
+// parameter, and records what Refresh fetched.
+type versionedClient struct {
+	fakeClient
+	versions  map[string]int64
+	pathCalls int
+	fetched   [][]string
+}
+
+func (c *versionedClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	c.pathCalls++
+	output, err := c.fakeClient.GetParametersByPath(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range output.Parameters {
+		output.Parameters[i].Version = c.versions[*output.Parameters[i].Name]
+	}
+	return output, nil
+}
+
+func (c *versionedClient) GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	c.fetched = append(c.fetched, params.Names)
+	var output ssm.GetParametersOutput
+	for _, name := range params.Names {
+		if value, ok := c.parameters[name]; ok {
+			output.Parameters = append(output.Parameters, types.Parameter{Name: aws.String(name), Value: aws.String(value), Version: c.versions[name]})
+		}
+	}
+	return &output, nil
+}
+
+func (c *versionedClient) DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+	prefix := strings.TrimSuffix(params.ParameterFilters[0].Values[0], "/") + "/"
+	var output ssm.DescribeParametersOutput
+	for name := range c.parameters {
+		if strings.HasPrefix(name, prefix) {
+			output.Parameters = append(output.Parameters, types.ParameterMetadata{Name: aws.String(name), Version: c.versions[name]})
+		}
+	}
+	return &output, nil
+}
+
+func TestRefresher(t *testing.T) {
+	client := &versionedClient{
+		fakeClient: fakeClient{parameters: map[string]string{"/HasTags/Foo": "foo", "/HasTags/OptionalBar": "bar"}},
+		versions:   map[string]int64{"/HasTags/Foo": 1, "/HasTags/OptionalBar": 1},
+	}
+	var v hasTags
+	refresher := NewRefresher(&v, "/HasTags", client)
+	if err := refresher.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo" || v.OptionalBar != "bar" || client.pathCalls != 1 {
+		t.Fatalf("unexpected first refresh: %+v after %d calls", v, client.pathCalls)
+	}
+
+	if err := refresher.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if client.pathCalls != 1 || len(client.fetched) != 0 {
+		t.Errorf("refetched unchanged parameters: %d path calls, %v", client.pathCalls, client.fetched)
+	}
+
+	client.parameters["/HasTags/Foo"] = "foo2"
+	client.versions["/HasTags/Foo"] = 2
+	if err := refresher.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo2" || v.OptionalBar != "bar" || !reflect.DeepEqual(client.fetched, [][]string{{"/HasTags/Foo"}}) {
+		t.Errorf("unexpected refresh: %+v after fetching %v", v, client.fetched)
+	}
+
+	delete(client.parameters, "/HasTags/OptionalBar")
+	if err := refresher.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if client.pathCalls != 2 {
+		t.Errorf("got %d path calls, want a full refresh after a deletion", client.pathCalls)
+	}
+}
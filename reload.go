@@ -0,0 +1,85 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// ReloadableConfig holds a *T loaded from SSM and reloads it on demand,
+// such as on SIGHUP. Each reload fills in a new T, which replaces the
+// current one only if Send succeeds, so a *T returned by Get is never
+// modified.
+type ReloadableConfig[T any] struct {
+	current atomic.Pointer[T]
+	path    string
+	client  PathFetcher
+	opts    []Option
+
+	lock sync.Mutex
+	err  error
+}
+
+// NewReloadableConfig loads a T from path, returning the error from Send if
+// that fails. It then reloads it each time trigger receives, until ctx is
+// done or trigger is closed. Use signal.Notify to have trigger receive
+// SIGHUP.
+func NewReloadableConfig[T any](ctx context.Context, path string, client PathFetcher, trigger <-chan os.Signal, opts ...Option) (*ReloadableConfig[T], error) {
+	c := &ReloadableConfig[T]{path: path, client: client, opts: opts}
+	if err := c.Reload(ctx); err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-trigger:
+				if !ok {
+					return
+				}
+				_ = c.Reload(ctx)
+			}
+		}
+	}()
+	return c, nil
+}
+
+// Get returns the most recently loaded config. Callers must not modify it.
+func (c *ReloadableConfig[T]) Get() *T {
+	return c.current.Load()
+}
+
+// Reload loads a new T now, replacing the current one if it succeeds.
+func (c *ReloadableConfig[T]) Reload(ctx context.Context) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	v, err := Load[T](ctx, c.path, c.client, c.opts...)
+	c.err = err
+	if err == nil {
+		c.current.Store(v)
+	}
+	return err
+}
+
+// Err returns the error from the latest reload, or nil if it succeeded.
+func (c *ReloadableConfig[T]) Err() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.err
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// reloadClient is a fakeClient whose parameters may change while a reload
+// is running.
+type reloadClient struct {
+	lock sync.Mutex
+	fakeClient
+}
+
+func (c *reloadClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.fakeClient.GetParametersByPath(ctx, params, optFns...)
+}
+
+func (c *reloadClient) set(name, value string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if value == "" {
+		delete(c.parameters, name)
+	} else {
+		c.parameters[name] = value
+	}
+}
+
+func TestReloadableConfig(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &reloadClient{fakeClient: fakeClient{parameters: map[string]string{"/HasTags/Foo": "v1"}}}
+	trigger := make(chan os.Signal, 1)
+	c, err := NewReloadableConfig[hasTags](ctx, "/HasTags", client, trigger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := c.Get()
+	if first.Foo != "v1" {
+		t.Fatalf("unexpected config: %+v", first)
+	}
+
+	client.set("/HasTags/Foo", "v2")
+	trigger <- syscall.SIGHUP
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Get().Foo != "v2" {
+		if time.Now().After(deadline) {
+			t.Fatal("config wasn't reloaded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if first.Foo != "v1" {
+		t.Fatalf("reload modified an earlier config: %+v", first)
+	}
+
+	client.set("/HasTags/Foo", "")
+	var missing MissingParameters
+	if err := c.Reload(ctx); !errors.As(err, &missing) || !errors.As(c.Err(), &missing) {
+		t.Fatalf("expected missing parameters, got %v", err)
+	}
+	if c.Get().Foo != "v2" {
+		t.Fatalf("failed reload replaced the config: %+v", c.Get())
+	}
+
+	if _, err := NewReloadableConfig[hasTags](ctx, "/Absent", client, trigger); err == nil {
+		t.Fatal("expected error from the initial load")
+	}
+}
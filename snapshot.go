@@ -0,0 +1,163 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+type snapshot struct {
+	Path       string              `json:"path"`
+	Time       time.Time           `json:"time"`
+	Parameters []snapshotParameter `json:"parameters"`
+}
+
+type snapshotParameter struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Value    string `json:"value,omitempty"`
+	Version  int64  `json:"version"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// SnapshotPath captures every parameter under path, recursively, as JSON
+// suitable for LoadFromSnapshot. SecureString values are included unless
+// WithRedactSecureStrings is passed, and no value is with
+// WithRedactPolicy(RedactAll).
+func SnapshotPath(ctx context.Context, client PathFetcher, path string, opts ...Option) ([]byte, error) {
+	config := newRequestConfig(opts)
+	path = normalizeName(path)
+	redactAll := config.redactPolicy == RedactAll
+
+	input := ssm.GetParametersByPathInput{
+		Path:           &path,
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(!config.redactSecure && !redactAll),
+	}
+
+	s := snapshot{
+		Path:       path,
+		Time:       time.Now().UTC(),
+		Parameters: []snapshotParameter{},
+	}
+	paginator := ssm.NewGetParametersByPathPaginator(client, &input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, parameter := range page.Parameters {
+			p := snapshotParameter{
+				Name:    aws.ToString(parameter.Name),
+				Type:    string(parameter.Type),
+				Version: parameter.Version,
+			}
+			if redactAll || config.redactSecure && parameter.Type == types.ParameterTypeSecureString {
+				p.Redacted = true
+			} else {
+				p.Value = aws.ToString(parameter.Value)
+			}
+			s.Parameters = append(s.Parameters, p)
+		}
+	}
+	sort.Slice(s.Parameters, func(i, j int) bool {
+		return s.Parameters[i].Name < s.Parameters[j].Name
+	})
+
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// LoadFromSnapshot populates configurable from data produced by SnapshotPath,
+// exactly as Send would have from the live parameters. Redacted parameters
+// are treated as absent.
+func LoadFromSnapshot(configurable interface{}, path string, data []byte, opts ...Option) error {
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	r, err := TryNewRequest(configurable, path, &snapshotClient{snapshot: s}, opts...)
+	if err != nil {
+		return err
+	}
+	return r.Send(context.Background())
+}
+
+type snapshotClient struct {
+	snapshot snapshot
+}
+
+const snapshotPageSize = 10
+
+func (c *snapshotClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	name := normalizeName(aws.ToString(params.Name))
+	for _, p := range c.snapshot.Parameters {
+		if p.Name == name && !p.Redacted {
+			return &ssm.GetParameterOutput{Parameter: &types.Parameter{
+				Name:    aws.String(p.Name),
+				Type:    types.ParameterType(p.Type),
+				Value:   aws.String(p.Value),
+				Version: p.Version,
+			}}, nil
+		}
+	}
+	return nil, &types.ParameterNotFound{}
+}
+
+func (c *snapshotClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	prefix := strings.TrimSuffix(aws.ToString(params.Path), "/") + "/"
+	var matched []types.Parameter
+	for _, p := range c.snapshot.Parameters {
+		if p.Redacted || !strings.HasPrefix(p.Name, prefix) {
+			continue
+		}
+		if !aws.ToBool(params.Recursive) && strings.Contains(p.Name[len(prefix):], "/") {
+			continue
+		}
+		matched = append(matched, types.Parameter{
+			Name:    aws.String(p.Name),
+			Type:    types.ParameterType(p.Type),
+			Value:   aws.String(p.Value),
+			Version: p.Version,
+		})
+	}
+
+	start := 0
+	if params.NextToken != nil {
+		var err error
+		if start, err = strconv.Atoi(*params.NextToken); err != nil {
+			return nil, err
+		}
+	}
+	output := ssm.GetParametersByPathOutput{}
+	end := start + snapshotPageSize
+	if end < len(matched) {
+		output.NextToken = aws.String(strconv.Itoa(end))
+	} else {
+		end = len(matched)
+	}
+	if start < end {
+		output.Parameters = matched[start:end]
+	}
+	return &output, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	client := &fakeClient{
+		parameters: map[string]string{
+			"/HasTags/Foo":         "foo",
+			"/HasTags/OptionalBar": "bar",
+			"/HasTags/Sub/Deep":    "deep",
+		},
+		pageSize: 1,
+	}
+	data, err := SnapshotPath(context.Background(), client, "/HasTags/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v hasTags
+	if err := LoadFromSnapshot(&v, "/HasTags", data); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo" || v.OptionalBar != "bar" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+}
+
+func TestSnapshotRedactSecureStrings(t *testing.T) {
+	client := &fakeClient{
+		parameters: map[string]string{
+			"/HasTags/Foo":         "secret",
+			"/HasTags/OptionalBar": "bar",
+		},
+		secure: map[string]bool{"/HasTags/Foo": true},
+	}
+	data, err := SnapshotPath(context.Background(), client, "/HasTags", WithRedactSecureStrings())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v hasTags
+	err = LoadFromSnapshot(&v, "/HasTags", data)
+	var missing MissingParameters
+	if !errors.As(err, &missing) || len(missing) != 1 || missing[0] != "/HasTags/Foo" {
+		t.Fatalf("expected redacted parameter to be missing, got %v", err)
+	}
+	if v.OptionalBar != "bar" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+}
+
+func TestSnapshotRedactAll(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{"/HasTags/Foo": "foo", "/HasTags/OptionalBar": "bar"}}
+	data, err := SnapshotPath(context.Background(), client, "/HasTags", WithRedactPolicy(RedactAll))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(`"foo"`)) || bytes.Contains(data, []byte(`"bar"`)) {
+		t.Fatalf("expected every value redacted, got %s", data)
+	}
+}
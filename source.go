@@ -0,0 +1,85 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// externalField is a field fetched by a SourceFunc rather than from SSM.
+type externalField struct {
+	fetch SourceFunc
+	id    string
+}
+
+// bindSource records how to fetch name if its field is tagged with the
+// modifier of a WithSource.
+func (r *request) bindSource(name string, t fieldTag) error {
+	var modifiers []string
+	for modifier := range r.config.sources {
+		if t.has(modifier) {
+			modifiers = append(modifiers, modifier)
+		}
+	}
+	if len(modifiers) == 0 {
+		return nil
+	}
+	if len(modifiers) > 1 {
+		sort.Strings(modifiers)
+		return errors.New("tagged with more than one source: " + modifiers[0] + " and " + modifiers[1])
+	}
+	id := t.modifiers[modifiers[0]]
+	if id == "" {
+		id = name
+	}
+	r.external[name] = externalField{fetch: r.config.sources[modifiers[0]], id: id}
+	delete(r.byName, name)
+	return nil
+}
+
+// hasSource reports whether a field tagged t is fetched by a WithSource
+// rather than from SSM.
+func (c *requestConfig) hasSource(t fieldTag) bool {
+	for modifier := range c.sources {
+		if t.has(modifier) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchExternal fetches the fields bound by bindSource, returning their
+// values by name.
+func (r *request) fetchExternal(ctx context.Context) (map[string]string, error) {
+	values := make(map[string]string, len(r.external))
+	names := make([]string, 0, len(r.external))
+	for name := range r.external {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		e := r.external[name]
+		value, found, err := e.fetch(ctx, e.id)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
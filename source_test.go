@@ -0,0 +1,58 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithSource(t *testing.T) {
+	client := &recordingClient{fakeClient: fakeClient{parameters: map[string]string{"/app/Secret": "from ssm"}}}
+	var ids []string
+	vault := WithSource("vault", func(ctx context.Context, id string) (string, bool, error) {
+		ids = append(ids, id)
+		return "from vault", id == "/app/Secret", nil
+	})
+	var v struct {
+		Secret string `ssm:"Secret,vault"`
+	}
+	if err := NewRequest(&v, "/app", client, vault).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Secret != "from vault" || len(client.inputs) != 0 {
+		t.Errorf("unexpected result %+v after %d path fetches", v, len(client.inputs))
+	}
+
+	var w struct {
+		Secret string `ssm:"Secret,vault=kv/other"`
+	}
+	var missing MissingParameters
+	if err := NewRequest(&w, "/app", client, vault).Send(context.Background()); !errors.As(err, &missing) {
+		t.Errorf("expected missing parameters, got %v", err)
+	}
+	if ids[len(ids)-1] != "kv/other" {
+		t.Errorf("got id %q, want kv/other", ids[len(ids)-1])
+	}
+
+	other := WithSource("other", func(context.Context, string) (string, bool, error) { return "", false, nil })
+	var both struct {
+		Secret string `ssm:"Secret,vault,other"`
+	}
+	if _, err := TryNewRequest(&both, "/app", client, vault, other); err == nil {
+		t.Error("expected an error for two sources")
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ssmappconfig fills ssmconfig-tagged structs from an AWS AppConfig
+// configuration profile instead of SSM, without making the root package
+// depend on the AppConfig SDK.
+package ssmappconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/appconfigdata"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/retailnext/ssmconfig"
+)
+
+// APIClient is the subset of *appconfigdata.Client used by a Request.
+type APIClient interface {
+	StartConfigurationSession(ctx context.Context, params *appconfigdata.StartConfigurationSessionInput, optFns ...func(*appconfigdata.Options)) (*appconfigdata.StartConfigurationSessionOutput, error)
+	GetLatestConfiguration(ctx context.Context, params *appconfigdata.GetLatestConfigurationInput, optFns ...func(*appconfigdata.Options)) (*appconfigdata.GetLatestConfigurationOutput, error)
+}
+
+var _ APIClient = (*appconfigdata.Client)(nil)
+
+// ErrExistsUnsupported is returned by Exists, which needs SSM.
+var ErrExistsUnsupported = errors.New("ssmappconfig: Exists is not supported")
+
+// NewRequest is like ssmconfig.NewRequest, but Send fetches the JSON
+// document of the configuration profile and applies its keys in place of
+// parameters. Tags name keys as they would parameters under path "/", so
+// `ssm:"db/host"` is the host key of the db object. Arrays of scalars are
+// joined with commas to suit []string fields; anything else within an array
+// is passed as JSON.
+func NewRequest(configurable interface{}, profile appconfigdata.StartConfigurationSessionInput, client APIClient, opts ...ssmconfig.Option) ssmconfig.Request {
+	r, err := TryNewRequest(configurable, profile, client, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// TryNewRequest is like NewRequest, but returns an error rather than
+// panicking when configurable can't be bound.
+func TryNewRequest(configurable interface{}, profile appconfigdata.StartConfigurationSessionInput, client APIClient, opts ...ssmconfig.Option) (ssmconfig.Request, error) {
+	doc := &document{}
+	inner, err := ssmconfig.TryNewRequest(configurable, "/", doc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &request{Request: inner, document: doc, profile: profile, client: client}, nil
+}
+
+type request struct {
+	ssmconfig.Request
+	document *document
+	profile  appconfigdata.StartConfigurationSessionInput
+	client   APIClient
+	sent     atomic.Bool
+}
+
+func (r *request) Send(ctx context.Context) error {
+	if !r.sent.CompareAndSwap(false, true) {
+		return ssmconfig.ErrAlreadySent
+	}
+	if err := r.fetch(ctx); err != nil {
+		return err
+	}
+	return r.Request.Send(ctx)
+}
+
+func (r *request) SendWithProgress(ctx context.Context) (<-chan ssmconfig.Progress, func() error) {
+	err := ssmconfig.ErrAlreadySent
+	if r.sent.CompareAndSwap(false, true) {
+		err = r.fetch(ctx)
+	}
+	if err != nil {
+		progress := make(chan ssmconfig.Progress)
+		close(progress)
+		return progress, func() error { return err }
+	}
+	return r.Request.SendWithProgress(ctx)
+}
+
+func (r *request) Exists(ctx context.Context, client ssm.DescribeParametersAPIClient) ([]string, error) {
+	return nil, ErrExistsUnsupported
+}
+
+func (r *request) fetch(ctx context.Context) error {
+	profile := r.profile
+	session, err := r.client.StartConfigurationSession(ctx, &profile)
+	if err != nil {
+		return fmt.Errorf("starting appconfig session: %w", err)
+	}
+	output, err := r.client.GetLatestConfiguration(ctx, &appconfigdata.GetLatestConfigurationInput{
+		ConfigurationToken: session.InitialConfigurationToken,
+	})
+	if err != nil {
+		return fmt.Errorf("getting appconfig configuration: %w", err)
+	}
+	if contentType := aws.ToString(output.ContentType); contentType != "" {
+		if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "application/json" {
+			return fmt.Errorf("unsupported appconfig content type %q", contentType)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(output.Configuration))
+	decoder.UseNumber()
+	var root map[string]interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return fmt.Errorf("decoding appconfig configuration: %w", err)
+	}
+	r.document.parameters = nil
+	for key, value := range root {
+		r.document.add("/"+key, value)
+	}
+	sort.Slice(r.document.parameters, func(i, j int) bool {
+		return *r.document.parameters[i].Name < *r.document.parameters[j].Name
+	})
+	return nil
+}
+
+// document serves the keys of a configuration document as parameters to
+// the ssmconfig.Request that applies them.
+type document struct {
+	parameters []types.Parameter
+}
+
+func (d *document) add(name string, value interface{}) {
+	switch value := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		for key, child := range value {
+			d.add(name+"/"+key, child)
+		}
+	default:
+		d.parameters = append(d.parameters, types.Parameter{
+			Name:  aws.String(name),
+			Value: aws.String(encode(value)),
+			Type:  types.ParameterTypeString,
+		})
+	}
+}
+
+func encode(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	case []interface{}:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}, nil:
+				b, _ := json.Marshal(value)
+				return string(b)
+			}
+			items = append(items, encode(item))
+		}
+		return strings.Join(items, ",")
+	}
+	b, _ := json.Marshal(value)
+	return string(b)
+}
+
+func (d *document) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	return &ssm.GetParametersByPathOutput{Parameters: d.parameters}, nil
+}
+
+func (d *document) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	for i, p := range d.parameters {
+		if *p.Name == aws.ToString(params.Name) {
+			return &ssm.GetParameterOutput{Parameter: &d.parameters[i]}, nil
+		}
+	}
+	return nil, &types.ParameterNotFound{}
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmappconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/appconfigdata"
+	"github.com/retailnext/ssmconfig"
+)
+
+type fakeClient struct {
+	contentType string
+	document    string
+	profile     appconfigdata.StartConfigurationSessionInput
+}
+
+func (c *fakeClient) StartConfigurationSession(ctx context.Context, params *appconfigdata.StartConfigurationSessionInput, optFns ...func(*appconfigdata.Options)) (*appconfigdata.StartConfigurationSessionOutput, error) {
+	c.profile = *params
+	return &appconfigdata.StartConfigurationSessionOutput{InitialConfigurationToken: aws.String("token")}, nil
+}
+
+func (c *fakeClient) GetLatestConfiguration(ctx context.Context, params *appconfigdata.GetLatestConfigurationInput, optFns ...func(*appconfigdata.Options)) (*appconfigdata.GetLatestConfigurationOutput, error) {
+	if aws.ToString(params.ConfigurationToken) != "token" {
+		return nil, errors.New("unexpected token")
+	}
+	return &appconfigdata.GetLatestConfigurationOutput{
+		Configuration: []byte(c.document),
+		ContentType:   aws.String(c.contentType),
+	}, nil
+}
+
+var profile = appconfigdata.StartConfigurationSessionInput{
+	ApplicationIdentifier:          aws.String("app"),
+	ConfigurationProfileIdentifier: aws.String("config"),
+	EnvironmentIdentifier:          aws.String("prod"),
+}
+
+type service struct {
+	Name     string   `ssm:"name"`
+	Port     uint16   `ssm:"db/port"`
+	Debug    bool     `ssm:"debug"`
+	Admins   []string `ssm:"admins"`
+	Limits   string   `ssm:"limits"`
+	Optional string   `ssm:"optional,optional"`
+}
+
+func TestRequest(t *testing.T) {
+	client := &fakeClient{
+		contentType: "application/json; charset=utf-8",
+		document:    `{"name": "api", "db": {"port": 5432}, "debug": true, "admins": ["ada", "bo"], "limits": [{"cpu": 2}], "optional": null}`,
+	}
+	var v service
+	req := NewRequest(&v, profile, client)
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expected := service{Name: "api", Port: 5432, Debug: true, Admins: []string{"ada", "bo"}, Limits: `[{"cpu":2}]`}
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+	if aws.ToString(client.profile.EnvironmentIdentifier) != "prod" {
+		t.Fatalf("unexpected profile: %+v", client.profile)
+	}
+	if _, err := req.Exists(context.Background(), nil); !errors.Is(err, ErrExistsUnsupported) {
+		t.Fatalf("expected ErrExistsUnsupported, got %v", err)
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	var v service
+	client := &fakeClient{contentType: "application/json", document: `{"name": "api", "db": {"port": "many"}}`}
+	err := NewRequest(&v, profile, client).Send(context.Background())
+	var parseErrors ssmconfig.ParseErrors
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/db/port" {
+		t.Fatalf("expected parse error for /db/port, got %v", err)
+	}
+
+	client.document = `{"name": "api", "db": {"port": 5432}}`
+	err = NewRequest(&v, profile, client).Send(context.Background())
+	var missing ssmconfig.MissingParameters
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/admins", "/debug", "/limits"}) {
+		t.Fatalf("expected missing keys, got %v", err)
+	}
+
+	client.contentType = "application/x-yaml"
+	if err := NewRequest(&v, profile, client).Send(context.Background()); err == nil {
+		t.Fatal("expected error for YAML content")
+	}
+}
@@ -16,18 +16,100 @@ package ssmconfig
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
 )
 
 type Request interface {
+	// Send fetches the parameters and sets the fields bound to them. A
+	// Request can only be sent once; Send returns ErrAlreadySent after that.
 	Send(ctx context.Context) error
+
+	// ParameterNames returns the sorted names of every parameter bound to a
+	// field, required or not.
+	ParameterNames() []string
+	// RequiredNames returns the sorted names of the parameters Send reports
+	// missing if it doesn't find them, as tagged: WithConditionalRequired
+	// isn't settled until Send.
+	RequiredNames() []string
+	// OptionalNames returns the sorted names of the other parameters of
+	// ParameterNames.
+	OptionalNames() []string
+	// Resolved returns the sorted names of the parameters found and applied
+	// by Send.
+	Resolved() []string
+	// Missing returns the sorted names of the required parameters Send did
+	// not find.
+	Missing() []string
+	// AssertComplete returns an *IncompleteError naming the required fields
+	// left unset, or nil once Send has set them all. It lets a caller that
+	// tolerated the MissingParameters of Send check again later.
+	AssertComplete() error
+	// DescribeBindings maps the path of each bound field (its Go name, with
+	// nested fields joined by ".") to the kind of decoder that sets it.
+	DescribeBindings() map[string]string
+	// Timings reports how long Send spent, when built with WithTiming.
+	Timings() Timings
+	// SendWithProgress is like Send, but sends in the background. The
+	// channel receives the latest Progress after each page and once more
+	// when Send returns, then closes. Progress a slow reader hasn't received
+	// is replaced rather than delay Send. The returned func waits for Send
+	// and returns its error.
+	SendWithProgress(ctx context.Context) (<-chan Progress, func() error)
+	// Exists returns the sorted names of the required parameters that don't
+	// exist, using DescribeParameters so that no values are read or
+	// decrypted. Fields made required by WithConditionalRequired aren't
+	// checked, as that depends on the values, nor are subtree, indexed and
+	// map fields or names given as ARNs.
+	Exists(ctx context.Context, client ssm.DescribeParametersAPIClient) (missing []string, err error)
+	// Set applies value to the field at fieldPath, as named by
+	// DescribeBindings, as if Send had fetched it, along with any other
+	// field bound to the same parameter. It must be called before Send,
+	// which then succeeds with a nil client if every required field was Set,
+	// or fetches the rest, replacing fields Set with any values fetched.
+	// Once Send has started, Set returns an error wrapping ErrAlreadySent
+	// without waiting for it.
+	Set(fieldPath, value string) error
+	// AddParameter requires the parameter with the given full name in
+	// addition to those bound to fields, and calls set with its value. It
+	// must be called before Send, and panics once Send has started rather
+	// than change the parameters Send is fetching.
+	AddParameter(fullName string, set func(value string))
+	// WriteEnv writes the values applied by Send to w as sorted KEY=VALUE
+	// lines, one per parameter, quoting values as needed for a shell to
+	// source them. See WithEnvOmitSecure.
+	WriteEnv(w io.Writer) error
+	// ResolvedValues maps the names of Resolved to the values Send applied,
+	// for display or logging. Values are redacted as set by
+	// WithRedactPolicy: by default, those of SecureString parameters and
+	// fields tagged secure are RedactedValue.
+	ResolvedValues() map[string]string
+	// Paths returns what Send fetches from SSM, sorted: the base paths it
+	// fetches by path and the names it fetches by name, such as those of
+	// path modifiers. It helps write the IAM policy a Request needs.
+	Paths() []RequestPath
+}
+
+// Timings breaks down the time spent by Send.
+type Timings struct {
+	// Pages holds the duration of each page fetched by path, in order and
+	// across every attempt made by WithMissingRetry.
+	Pages []time.Duration
+	// Secure holds the duration of the last call made for each field by
+	// WithIsolateSecureFetches.
+	Secure map[string]time.Duration
+	Total  time.Duration
 }
 
 type MissingParameters []string
@@ -36,96 +118,1138 @@ func (e MissingParameters) Error() string {
 	return fmt.Sprintf("missing ssm parameters: %+v", []string(e))
 }
 
-func NewRequest(configurable interface{}, path string, client ssm.GetParametersByPathAPIClient) Request {
-	path = "/" + strings.Trim(path, "/")
+// MissingFieldsError is returned by Send when required parameters are
+// missing, naming the fields that expected each, as in
+// "missing ssm parameters: /app/db/host (field Config.DBHost)". It unwraps
+// to the MissingParameters.
+type MissingFieldsError struct {
+	Missing MissingParameters
+	// Fields maps each missing name to the paths of the fields bound to it,
+	// prefixed by the name of the struct type if it has one. A parameter
+	// added by AddParameter has no fields.
+	Fields map[string][]string
+}
+
+func (e *MissingFieldsError) Error() string {
+	names := make([]string, 0, len(e.Missing))
+	for _, name := range e.Missing {
+		switch fields := e.Fields[name]; len(fields) {
+		case 0:
+			names = append(names, name)
+		case 1:
+			names = append(names, fmt.Sprintf("%s (field %s)", name, fields[0]))
+		default:
+			names = append(names, fmt.Sprintf("%s (fields %s)", name, strings.Join(fields, ", ")))
+		}
+	}
+	return fmt.Sprintf("missing ssm parameters: %s", strings.Join(names, ", "))
+}
+
+func (e *MissingFieldsError) Unwrap() error {
+	return e.Missing
+}
+
+// IncompleteError is returned by AssertComplete.
+type IncompleteError struct {
+	// Fields holds the sorted paths of the unset fields, each followed by
+	// its parameter name, as in "URL (/app/url)". A parameter added
+	// by AddParameter is listed by name alone.
+	Fields  []string
+	Missing MissingParameters
+}
+
+func (e *IncompleteError) Error() string {
+	return fmt.Sprintf("required fields unset: %s", strings.Join(e.Fields, ", "))
+}
+
+func (e *IncompleteError) Unwrap() error {
+	return e.Missing
+}
+
+type ParseError struct {
+	Name string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid ssm parameter %s: %v", e.Name, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("invalid ssm parameters: %s", strings.Join(messages, "; "))
+}
+
+// BudgetExceededError is returned by Send when the budget given to
+// WithTotalBudget runs out, wrapping the error that ended it: a
+// *CanceledError at the deadline, or the error of the fetch that would have
+// been retried past it.
+type BudgetExceededError struct {
+	Budget time.Duration
+	Err    error
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("ssm parameters not loaded within %v: %v", e.Budget, e.Err)
+}
+
+func (e *BudgetExceededError) Unwrap() error {
+	return e.Err
+}
+
+// CanceledError is returned by Send when its context is done before it
+// completes. The fields already set are left as they are, so Applied and
+// Missing tell whether they're usable.
+type CanceledError struct {
+	// Err wraps the context's error.
+	Err error
+	// Applied holds the sorted names of the parameters applied so far.
+	Applied []string
+	// Missing holds the sorted names of the required parameters not yet
+	// applied.
+	Missing []string
+}
+
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("ssm parameters partially applied (%d applied, %d missing): %v", len(e.Applied), len(e.Missing), e.Err)
+}
+
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// FieldError describes a tagged field that NewRequest can't bind.
+type FieldError struct {
+	FieldPath string
+	Type      reflect.Type
+	Err       error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("invalid field with ssm tag (%v): %s %s", e.Err, e.FieldPath, e.Type)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// FieldErrors lists every field of a configurable that NewRequest can't
+// bind, in field order.
+type FieldErrors []*FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+var ErrNilConfigurable = errors.New("ssmconfig: configurable pointer is nil")
+
+// ErrAlreadySent is returned by Send on a Request that was sent before,
+// which it leaves alone.
+var ErrAlreadySent = errors.New("ssmconfig: request already sent")
+
+// PathFetcher is the subset of *ssm.Client a Request needs to fetch
+// parameters by path. It's all NewRequest requires, so tests and wrappers
+// only have to implement the one method.
+type PathFetcher interface {
+	GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
+}
+
+var _ PathFetcher = (*ssm.Client)(nil)
+
+// NewRequest binds the tagged fields of the struct configurable points to
+// relative to path. It panics if configurable can't be bound.
+//
+// path may have one * segment, as in /app/*/config, when a part of it
+// varies between deployments. Send then resolves it before fetching, at the
+// cost of a DescribeParameters call per 50 parameters under the part before
+// the *, which needs client to implement ssm.DescribeParametersAPIClient. Of
+// the children under which the rest of path exists, Send picks the one
+// holding the most recently modified parameter, and the greatest name among
+// those modified at the same time. Until Send, ParameterNames reports names
+// under the path as given.
+func NewRequest(configurable interface{}, path string, client PathFetcher, opts ...Option) Request {
+	return mustRequest(TryNewRequest(configurable, path, client, opts...))
+}
+
+// TryNewRequest is like NewRequest, but returns an error rather than
+// panicking when configurable can't be bound.
+func TryNewRequest(configurable interface{}, path string, client PathFetcher, opts ...Option) (Request, error) {
+	path = normalizeName(path)
 
 	input := ssm.GetParametersByPathInput{
 		Path:           &path,
 		WithDecryption: aws.Bool(true),
 	}
 
-	v := reflect.ValueOf(configurable)
-	if v.Kind() != reflect.Ptr {
-		panic("configurable must be a pointer")
+	return newRequest(configurable, input, client, opts)
+}
+
+// NewRequestWithInput is like NewRequest, but fetches with the given input
+// as-is. Field names are resolved relative to input.Path, which must be set.
+func NewRequestWithInput(configurable interface{}, input ssm.GetParametersByPathInput, client PathFetcher, opts ...Option) Request {
+	if aws.ToString(input.Path) == "" {
+		panic("input.Path must be set")
+	}
+	return mustRequest(newRequest(configurable, input, client, opts))
+}
+
+func mustRequest(r Request, err error) Request {
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func newRequest(configurable interface{}, input ssm.GetParametersByPathInput, client PathFetcher, opts []Option) (Request, error) {
+	path := normalizeName(*input.Path)
+
+	v, err := configurableValue(configurable)
+	if err != nil {
+		return nil, err
+	}
+
+	config := newRequestConfig(opts)
+	if config.tagVariables != nil {
+		if path, err = config.expandPath(path); err != nil {
+			return nil, err
+		}
+		input.Path = &path
+	}
+	if config.namePrefix != "" {
+		path = config.prefixed(path)
+		input.Path = &path
+		if fn := config.pathFunc; fn != nil {
+			config.pathFunc = func(ctx context.Context) (string, error) {
+				path, err := fn(ctx)
+				return config.prefixed(normalizeName(path)), err
+			}
+		}
+	}
+	if config.jsonBundle != "" {
+		config.jsonBundle = config.mirrored(config.jsonBundle)
+	}
+	if err := validateAllowlist(config.nameAllowlist); err != nil {
+		return nil, err
+	}
+	if config.noDecryption {
+		input.WithDecryption = aws.Bool(false)
+	}
+	if fn, err := wildcardPathFunc(path, client, &config); err != nil {
+		return nil, err
+	} else if fn != nil {
+		config.pathFunc = fn
 	}
-	v = v.Elem()
 
 	r := request{
-		missing:   make(map[string]struct{}, v.NumField()),
-		setters:   make(map[string][]func(string), v.NumField()),
-		paginator: ssm.NewGetParametersByPathPaginator(client, &input),
+		applyLock:    config.applyLock,
+		config:       config,
+		configurable: configurable,
+		value:        v,
+		input:        input,
+		client:       client,
+	}
+	if err := r.bindRoot(path); err != nil {
+		return nil, err
 	}
 
-	for i := 0; i < v.NumField(); i++ {
-		tag := v.Type().Field(i).Tag.Get(tagName)
-		if tag == "" {
+	return &r, nil
+}
+
+// configurableValue returns the struct configurable points to, following
+// any further pointers and interfaces on the way, as for a **T or a pointer
+// to an interface{} holding a *T. A nil pointer reached through a pointer
+// is set to point to a new zero value.
+func configurableValue(configurable interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(configurable)
+	if v.Kind() != reflect.Ptr {
+		return reflect.Value{}, errors.New("configurable must be a pointer")
+	}
+	if v.IsNil() {
+		return reflect.Value{}, ErrNilConfigurable
+	}
+	for v = v.Elem(); v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface; v = v.Elem() {
+		if !v.IsNil() {
 			continue
 		}
-		tagParts := strings.Split(tag, ",")
-		if len(tagParts) == 0 {
-			continue
+		if v.Kind() == reflect.Interface || !v.CanSet() {
+			return reflect.Value{}, ErrNilConfigurable
 		}
-		suffix := strings.Trim(tagParts[0], "/")
-		name := path + "/" + suffix
-		optional := len(tagParts) > 1 && tagParts[1] == "optional"
+		v.Set(reflect.New(v.Type().Elem()))
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("configurable must point to a struct, not %s", v.Type())
+	}
+	if !v.CanSet() {
+		return reflect.Value{}, fmt.Errorf("configurable reaches %s through an interface holding it by value, so it can't be set", v.Type())
+	}
+	return v, nil
+}
+
+// bindRoot (re)binds every field of the configurable relative to path.
+func (r *request) bindRoot(path string) error {
+	v := r.value
+	r.missing = make(map[string]struct{}, v.NumField())
+	r.resolved = make(map[string]struct{}, v.NumField())
+	r.setters = make(map[string][]func(string) error, v.NumField())
+	r.subtrees = nil
+	r.indexed = nil
+	r.maps = nil
+	r.positions = make(map[string]int)
+	r.defaults = nil
+	r.bindings = nil
+	r.byName = make(map[string]struct{})
+	r.secure = make(map[string]struct{})
+	r.keyIDs = make(map[string]string)
+	r.deprecated = make(map[string]string)
+	r.external = make(map[string]externalField)
 
-		f := v.Field(i)
-		if !f.CanSet() {
-			panic(fmt.Errorf("invalid field with ssm tag (can't set): %+v", f))
+	r.docs = nil
+
+	if plan := stringPlan(v.Type()); plan != nil && !r.config.jsonTagFallback && !r.config.docs && r.config.valueCase == CaseNone && r.config.tagVariables == nil {
+		r.bindStrings(v, path, plan)
+	} else if err := r.bind(v, path, ""); err != nil {
+		return err
+	}
+	if r.collectsUnmatched() && r.input.Recursive == nil {
+		r.input.Recursive = aws.Bool(true)
+	}
+	for _, e := range r.extras {
+		r.bindExtra(path, e)
+	}
+	for _, d := range r.defaults {
+		// A defaultFrom parameter the fetch by path won't return has to be
+		// fetched by name.
+		if d.from != "" && !underPath(path, d.from, aws.ToBool(r.input.Recursive)) {
+			r.byName[d.from] = struct{}{}
+		}
+	}
+	for _, c := range r.config.conditions {
+		if !strings.Contains(c.fieldPath, ".") && !r.hasField(c.fieldPath) {
+			return fmt.Errorf("conditionally required field %s has no ssm tag", c.fieldPath)
 		}
-		if f.Kind() != reflect.String {
-			panic(fmt.Errorf("invalid field with ssm tag (not a string): %+v", f))
+	}
+	if err := r.bindARNs(path); err != nil {
+		return err
+	}
+	if err := r.applyFetchStrategy(path); err != nil {
+		return err
+	}
+	if err := r.checkByNameClient(); err != nil {
+		return err
+	}
+	r.required = make(map[string]struct{}, len(r.missing))
+	for name := range r.missing {
+		r.required[name] = struct{}{}
+	}
+	return nil
+}
+
+// bind binds every tagged field of v, reporting every field it can't bind
+// rather than stopping at the first.
+func (r *request) bind(v reflect.Value, path, fieldPrefix string) error {
+	var errs FieldErrors
+	for _, tf := range fieldPlan(v.Type(), &r.config) {
+		fieldPath := fieldPrefix + tf.field.Name
+		bound := len(r.bindings)
+		if err := r.bindField(v.Field(tf.index), fieldPath, path, tf.tag); err != nil {
+			errs = append(errs, &FieldError{FieldPath: fieldPath, Type: tf.field.Type, Err: err})
+		} else if r.config.docs {
+			r.addDoc(fieldPath, tf.field.Type.String(), tf.tag, bound)
 		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
 
-		r.setters[name] = append(r.setters[name], f.SetString)
-		if !optional {
+func (r *request) bindField(f reflect.Value, fieldPath, path string, t fieldTag) error {
+	if err := t.expand(r.config.tagVariables); err != nil {
+		return err
+	}
+	if !f.CanSet() {
+		return errors.New("can't set")
+	}
+	if err := t.requiredIn(r.config.environment); err != nil {
+		return err
+	}
+	name, err := r.config.fieldName(path, t, r.positions)
+	if err != nil {
+		return err
+	}
+	_, hasOverride := t.modifiers["path"]
+	// A name given in full is fetched by name unless the fetch by path
+	// returns it anyway. A positioned name lies below its subtree, which
+	// the fetch by path only returns when recursive.
+	fetchByName := (hasOverride || t.has("position")) && !underPath(path, name, aws.ToBool(r.input.Recursive))
+	if fetchByName {
+		r.byName[name] = struct{}{}
+	}
+	if isMetadataTag(t) {
+		// Set by LoadWithMetadata.
+		return nil
+	}
+	if factory, ok := r.config.interfaceFactories[fieldPath]; ok && f.Kind() == reflect.Interface {
+		r.bindSubtree(f, name, fieldPath, t, factory)
+		return nil
+	}
+	if t.has("indexed") {
+		return r.bindIndexed(f, name, fieldPath, t)
+	}
+	if isSubtreeMap(f, t, &r.config) {
+		return r.bindSubtreeMap(f, name, fieldPath, t)
+	}
+	if list, ok := t.modifiers["inlist"]; ok {
+		// The field reports whether its name is a member of the list
+		// parameter, which is fetched by name as it may lie outside path.
+		setter, err := newListMemberSetter(f, t.suffix)
+		if err != nil {
+			return err
+		}
+		name = r.config.mirrored(normalizeName(list))
+		r.byName[name] = struct{}{}
+		r.bindings = append(r.bindings, binding{fieldPath: fieldPath, name: name, kind: "inlist"})
+		r.setters[name] = append(r.setters[name], setter)
+		if !t.optional {
 			r.missing[name] = struct{}{}
 		}
+		return nil
+	}
+	setter, kind, err := newSetter(f, t, &r.config)
+	if err != nil {
+		return err
+	}
+	if fetchByName {
+		// A leaf is fetched by its name with the suffix of WithNameSuffix.
+		delete(r.byName, name)
+		r.byName[r.config.suffixed(name)] = struct{}{}
+	}
+	name = r.config.suffixed(name)
+	if t.has("self") {
+		if t.suffix != "" {
+			return errors.New("self with a name")
+		}
+		r.byName[name] = struct{}{}
+	}
+	from, hasFrom := t.modifiers["defaultFrom"]
+	if hasFrom && !t.optional {
+		return errors.New("defaultFrom without optional")
+	}
+
+	r.bindings = append(r.bindings, binding{fieldPath: fieldPath, name: name, kind: kind})
+	r.setters[name] = append(r.setters[name], setter)
+	if t.has("secure") {
+		r.secure[name] = struct{}{}
+	}
+	if err := r.bindKeyID(name, t); err != nil {
+		return err
+	}
+	if message, ok := t.modifiers["deprecated"]; ok {
+		r.deprecated[name] = message
+	}
+	if err := r.bindSource(name, t); err != nil {
+		return err
+	}
+	if hasFrom {
+		d := fieldDefault{name: name, from: r.config.mirrored(normalizeName(from)), fromValue: new(string), setter: setter}
+		r.setters[d.from] = append(r.setters[d.from], func(value string) error {
+			*d.fromValue = value
+			return nil
+		})
+		r.defaults = append(r.defaults, d)
+	} else if value, ok := t.modifiers["default"]; ok {
+		r.defaults = append(r.defaults, fieldDefault{name: name, value: value, setter: setter})
+	} else if !t.optional {
+		r.missing[name] = struct{}{}
+	}
+	return nil
+}
+
+// extraParameter is a parameter added by AddParameter.
+type extraParameter struct {
+	name string
+	set  func(value string)
+}
+
+func (r *request) Set(fieldPath, value string) error {
+	if r.frozen.Load() {
+		return errSetAfterSend
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.done {
+		return errSetAfterSend
+	}
+	for _, b := range r.bindings {
+		if b.fieldPath != fieldPath {
+			continue
+		}
+		if r.preset == nil {
+			r.fetched = make(map[string]struct{})
+			r.values = make(map[string]string)
+			r.preset = make(map[string]string)
+		}
+		_, missing := r.missing[b.name]
+		if parseErrors := r.apply(b.name, value, nil); len(parseErrors) > 0 {
+			// Unlike a fetched value, one Set in error is left unset.
+			if missing {
+				r.missing[b.name] = struct{}{}
+			}
+			delete(r.fetched, b.name)
+			return parseErrors
+		}
+		r.preset[b.fieldPath] = value
+		return nil
+	}
+	return fmt.Errorf("%w: %s", errNoField, fieldPath)
+}
+
+var errNoField = errors.New("no field bound at")
+
+var errSetAfterSend = fmt.Errorf("Set called after Send: %w", ErrAlreadySent)
+
+func (r *request) AddParameter(fullName string, set func(value string)) {
+	if r.frozen.Load() {
+		panic(errAddAfterSend)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.done {
+		panic(errAddAfterSend)
+	}
+	e := extraParameter{name: r.config.mirrored(normalizeName(fullName)), set: set}
+	r.extras = append(r.extras, e)
+	path := normalizeName(aws.ToString(r.input.Path))
+	r.bindExtra(path, e)
+	if err := r.applyFetchStrategy(path); err != nil {
+		panic(fmt.Sprintf("can't fetch %s: %v", e.name, err))
+	}
+	if err := r.checkByNameClient(); err != nil {
+		panic(fmt.Sprintf("can't fetch %s: %v", e.name, err))
+	}
+}
+
+const errAddAfterSend = "parameter added after Send"
+
+func (r *request) bindExtra(path string, e extraParameter) {
+	r.setters[e.name] = append(r.setters[e.name], func(value string) error {
+		e.set(value)
+		return nil
+	})
+	r.missing[e.name] = struct{}{}
+	if r.required != nil {
+		r.required[e.name] = struct{}{}
+	}
+	if isARN(e.name) || !underPath(path, e.name, aws.ToBool(r.input.Recursive)) {
+		r.byName[e.name] = struct{}{}
+	}
+}
+
+func (r *request) hasField(fieldPath string) bool {
+	for _, b := range r.bindings {
+		if b.fieldPath == fieldPath {
+			return true
+		}
 	}
+	return false
+}
+
+// binding records how a field was bound, in field declaration order.
+type binding struct {
+	fieldPath string
+	name      string
+	kind      string
+}
 
-	return &r
+// fieldDefault is applied to a field whose parameter wasn't fetched. With
+// from set, the value is that of the from parameter, if it was fetched.
+type fieldDefault struct {
+	name      string
+	value     string
+	from      string
+	fromValue *string
+	setter    func(string) error
 }
 
 const tagName = "ssm"
 
+// fieldTagOf returns the ssm tag of field, falling back to the name in its
+// json tag under WithJSONTagFallback.
+func fieldTagOf(field reflect.StructField, config *requestConfig) string {
+	if tag, ok := field.Tag.Lookup(tagName); ok || !config.jsonTagFallback {
+		return tag
+	}
+	tag, ok := field.Tag.Lookup("json")
+	if !ok || tag == "-" {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	// Escape the name so that parseTag reads it back as-is.
+	return strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(name)
+}
+
 type request struct {
-	lock      sync.Mutex
-	done      bool
-	missing   map[string]struct{}
-	setters   map[string][]func(string)
-	paginator *ssm.GetParametersByPathPaginator
+	lock         sync.Mutex
+	done         bool
+	frozen       atomic.Bool
+	config       requestConfig
+	configurable interface{}
+	value        reflect.Value
+	missing      map[string]struct{}
+	// required holds the names missing before Send.
+	required map[string]struct{}
+	resolved map[string]struct{}
+	// setters holds the setters bound to each name, which apply runs in the
+	// order they were bound: field declaration order, then AddParameter.
+	setters  map[string][]func(string) error
+	bindings []binding
+	// byName holds the names fetched one by one rather than by path.
+	byName map[string]struct{}
+	// secure holds the names of the fields tagged secure, and keyIDs the
+	// KMS key of those also tagged keyid.
+	secure map[string]struct{}
+	keyIDs map[string]string
+	// deprecated maps the names of the fields tagged deprecated to the
+	// message they're tagged with.
+	deprecated map[string]string
+	// external holds the names of the fields fetched by a WithSource.
+	external map[string]externalField
+	subtrees []*subtree
+	indexed  []indexedList
+	maps     []subtreeMap
+	defaults []fieldDefault
+	extras   []extraParameter
+	// positions holds the next element of each subtree bound by position.
+	positions map[string]int
+	// preset holds the values applied by Set, by field path, as the names
+	// of the fields may change with WithPathFunc.
+	preset  map[string]string
+	docs    []ParamDoc
+	fetched map[string]struct{}
+	// values holds the fetched value of each resolved name, and
+	// secureStrings the names fetched as a SecureString, for WriteEnv.
+	values        map[string]string
+	secureStrings map[string]struct{}
+	// versions holds the version of each parameter fetched, for Refresher.
+	versions map[string]int64
+	timings  Timings
+	// pages counts the pages fetched by path during Send, for a Tracer.
+	pages int
+	// deadline is when the budget of WithTotalBudget runs out.
+	deadline time.Time
+	// progress, when set, is called after each page is applied.
+	progress func(resolved int)
+	// applyLock, when set, is held while setters run. pending holds the pages
+	// fetched but not yet applied under WithApplyLock.
+	applyLock sync.Locker
+	pending   []pendingPage
+	input     ssm.GetParametersByPathInput
+	client    PathFetcher
 }
 
 func (r *request) Send(ctx context.Context) error {
+	// Set before taking the lock, so that Set and AddParameter fail rather
+	// than wait for it to be released.
+	r.frozen.Store(true)
 	r.lock.Lock()
 	defer r.lock.Unlock()
-	if !r.done {
-		r.done = true
-	} else {
-		panic("request executed more than once")
+	if r.done {
+		return ErrAlreadySent
+	}
+	r.done = true
+
+	if r.config.tracer == nil {
+		return r.sendWithinBudget(ctx)
 	}
+	ctx, end := r.config.tracer.StartSend(ctx)
+	r.pages = 0
+	err := r.sendWithinBudget(ctx)
+	end(SendTrace{Path: aws.ToString(r.input.Path), Parameters: len(r.fetched), Pages: r.pages}, err)
+	return err
+}
+
+func (r *request) send(ctx context.Context) error {
+	if r.config.timing {
+		start := time.Now()
+		defer func() {
+			r.timings.Total = time.Since(start)
+		}()
+	}
+
+	if r.config.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.config.timeout)
+		defer cancel()
+	}
+
+	if r.config.pathFunc != nil {
+		path, err := r.config.pathFunc(ctx)
+		if err != nil {
+			return err
+		}
+		path = normalizeName(path)
+		if err := r.bindRoot(path); err != nil {
+			return err
+		}
+		r.input.Path = &path
+		if parseErrors := r.applyPresets(); len(parseErrors) > 0 {
+			return parseErrors
+		}
+	}
+
+	err := r.fetch(ctx)
+	for attempt := 0; attempt < r.config.missingRetries; attempt++ {
+		if _, ok := err.(*MissingFieldsError); !ok {
+			break
+		}
+		if r.overBudget(r.config.missingRetryDelay) {
+			return &BudgetExceededError{Budget: r.config.budget, Err: err}
+		}
+		if err := sleep(ctx, r.config.missingRetryDelay); err != nil {
+			return r.canceled(ctx, err)
+		}
+		err = r.fetch(ctx)
+	}
+	r.reportDeprecated()
+	if err == nil {
+		err = r.checkKeyIDs(ctx)
+	}
+	if err != nil {
+		return r.canceled(ctx, err)
+	}
+
+	for _, afterSend := range r.config.afterSend {
+		if err := afterSend(r.configurable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	for r.paginator.HasMorePages() {
-		page, err := r.paginator.NextPage(ctx)
+// applyPresets applies the values given to Set again, to the names the
+// fields are bound to now.
+func (r *request) applyPresets() ParseErrors {
+	var parseErrors ParseErrors
+	for _, b := range r.bindings {
+		if value, ok := r.preset[b.fieldPath]; ok {
+			parseErrors = r.apply(b.name, value, parseErrors)
+		}
+	}
+	return parseErrors
+}
+
+func (r *request) fetch(ctx context.Context) error {
+	r.fetched = make(map[string]struct{})
+	r.values = make(map[string]string)
+	r.secureStrings = make(map[string]struct{})
+	r.versions = make(map[string]int64)
+	var parseErrors ParseErrors
+	var unmatched map[string]string
+	if r.collectsUnmatched() {
+		unmatched = make(map[string]string)
+	}
+
+	for _, b := range r.bindings {
+		if value, ok := r.preset[b.fieldPath]; ok {
+			r.fetched[b.name] = struct{}{}
+			r.values[b.name] = value
+		}
+	}
+	// A Request for tests may have no client, with every field given to Set.
+	if r.client != nil && r.needsPathFetch() {
+		var err error
+		if r.config.jsonBundle != "" {
+			parseErrors, err = r.fetchBundle(ctx, unmatched, parseErrors)
+		} else if r.config.splitDecryption && aws.ToBool(r.input.WithDecryption) {
+			parseErrors, err = r.fetchSplit(ctx, unmatched, parseErrors)
+		} else {
+			parseErrors, err = r.fetchPath(ctx, r.input, nil, unmatched, parseErrors)
+		}
 		if err != nil {
 			return err
 		}
-		for _, parameter := range page.Parameters {
-			for _, setter := range r.setters[*parameter.Name] {
-				setter(*parameter.Value)
+	}
+	if r.client != nil && len(r.byName) > 0 {
+		var err error
+		if parseErrors, err = r.fetchByName(ctx, parseErrors); err != nil {
+			return err
+		}
+	}
+
+	var external map[string]string
+	if len(r.external) > 0 {
+		var err error
+		if external, err = r.fetchExternal(ctx); err != nil {
+			return err
+		}
+	}
+
+	r.lockApply()
+	for name, value := range external {
+		parseErrors = r.apply(name, value, parseErrors)
+	}
+	for _, p := range r.pending {
+		var err error
+		if parseErrors, err = r.applyPage(p.parameters, p.secure, unmatched, parseErrors); err != nil {
+			r.unlockApply()
+			return err
+		}
+	}
+	r.pending = nil
+	if unmatched != nil {
+		parseErrors = r.resolveSubtrees(unmatched, parseErrors)
+		parseErrors = r.resolveIndexed(unmatched, parseErrors)
+		parseErrors = r.resolveMaps(unmatched, parseErrors)
+	}
+	for _, d := range r.defaults {
+		if _, ok := r.fetched[d.name]; ok {
+			continue
+		}
+		if d.from == "" {
+			if err := r.set(d.setter, d.value); err != nil {
+				parseErrors = append(parseErrors, &ParseError{Name: d.name, Err: fmt.Errorf("default: %w", err)})
 			}
-			delete(r.missing, *parameter.Name)
+			continue
+		}
+		if _, ok := r.fetched[d.from]; !ok {
+			continue
+		}
+		if err := r.set(d.setter, *d.fromValue); err != nil {
+			parseErrors = append(parseErrors, &ParseError{Name: d.name, Err: fmt.Errorf("default from %s: %w", d.from, err)})
 		}
 	}
+	r.unlockApply()
+	r.applyConditions()
+
+	if len(parseErrors) > 0 {
+		sort.SliceStable(parseErrors, func(i, j int) bool {
+			return parseErrors[i].Name < parseErrors[j].Name
+		})
+		return parseErrors
+	}
 
 	if len(r.missing) > 0 {
-		missingParameters := make(MissingParameters, 0, len(r.missing))
-		for name := range r.missing {
-			missingParameters = append(missingParameters, name)
-		}
-		sort.Strings(missingParameters)
-		return missingParameters
+		return r.missingError()
 	}
 
 	return nil
 }
+
+// fetchPath fetches input by path, applying each page as by applyPage.
+func (r *request) fetchPath(ctx context.Context, input ssm.GetParametersByPathInput, secure *bool, unmatched map[string]string, parseErrors ParseErrors) (ParseErrors, error) {
+	client := r.client
+	if r.config.contextCache {
+		client = cachingClient{client: client}
+	}
+	paginator := ssm.NewGetParametersByPathPaginator(client, &input)
+	for first := true; paginator.HasMorePages(); first = false {
+		start := time.Now()
+		pageCtx, endPage := r.startPage(ctx)
+		page, err := r.nextPage(pageCtx, paginator)
+		endPage(page, err)
+		if r.config.timing {
+			r.timings.Pages = append(r.timings.Pages, time.Since(start))
+		}
+		if err != nil && first && r.config.missingPathAsEmpty && isMissingPath(err) {
+			return parseErrors, nil
+		}
+		if err != nil {
+			return parseErrors, err
+		}
+		for _, observe := range r.config.pageObservers {
+			observe(page)
+		}
+		if parseErrors, err = r.applyOrDefer(page.Parameters, secure, unmatched, parseErrors); err != nil {
+			return parseErrors, err
+		}
+		if r.progress != nil {
+			r.progress(len(r.resolved))
+		}
+	}
+	return parseErrors, nil
+}
+
+// pendingPage is a page fetched under WithApplyLock, to be applied once
+// every page has been fetched.
+type pendingPage struct {
+	parameters []types.Parameter
+	secure     *bool
+}
+
+// applyOrDefer applies the parameters of a page now, or under WithApplyLock
+// leaves them for fetch to apply along with every other page.
+func (r *request) applyOrDefer(parameters []types.Parameter, secure *bool, unmatched map[string]string, parseErrors ParseErrors) (ParseErrors, error) {
+	if r.config.applyLock != nil {
+		r.pending = append(r.pending, pendingPage{parameters: parameters, secure: secure})
+		return parseErrors, nil
+	}
+	r.lockApply()
+	defer r.unlockApply()
+	return r.applyPage(parameters, secure, unmatched, parseErrors)
+}
+
+// applyPage applies the parameters of a page. Given secure, it applies only
+// the parameters whose secure modifier matches. Parameters without setters
+// are added to unmatched, if it's not nil.
+func (r *request) applyPage(parameters []types.Parameter, secure *bool, unmatched map[string]string, parseErrors ParseErrors) (ParseErrors, error) {
+	for _, parameter := range parameters {
+		name := normalizeName(*parameter.Name)
+		if ok, err := r.allowed(name); err != nil {
+			return parseErrors, err
+		} else if !ok {
+			continue
+		}
+		if secure != nil {
+			if _, ok := r.secure[name]; ok != *secure {
+				continue
+			}
+		}
+		if parameter.Type == types.ParameterTypeSecureString {
+			r.secureStrings[name] = struct{}{}
+		}
+		r.versions[name] = parameter.Version
+		if _, ok := r.external[name]; ok {
+			continue
+		}
+		// Checked before collecting unmatched parameters, so that the
+		// fields built from them never get ciphertext either.
+		if r.config.noDecryption && parameter.Type == types.ParameterTypeSecureString {
+			parseErrors = append(parseErrors, &ParseError{Name: name, Err: errors.New("SecureString fetched with decryption turned off")})
+			continue
+		}
+		if secure != nil && !*secure && parameter.Type == types.ParameterTypeSecureString {
+			parseErrors = append(parseErrors, &ParseError{Name: name, Err: errors.New("SecureString fetched without decryption for a field not tagged secure")})
+			continue
+		}
+		if _, ok := r.setters[name]; !ok {
+			if unmatched != nil {
+				unmatched[name] = *parameter.Value
+			}
+			continue
+		}
+		if err := r.checkSecure(name, parameter.Type); err != nil {
+			parseErrors = append(parseErrors, &ParseError{Name: name, Err: err})
+			continue
+		}
+		parseErrors = r.apply(name, *parameter.Value, parseErrors)
+	}
+	return parseErrors, nil
+}
+
+// canceled returns err as a *CanceledError if it's due to ctx being done.
+func (r *request) canceled(ctx context.Context, err error) error {
+	if ctx.Err() == nil || !errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return &CanceledError{
+		Err:     err,
+		Applied: sortedNames(r.resolved),
+		Missing: sortedNames(r.missing),
+	}
+}
+
+// applyConditions settles whether the fields given to WithConditionalRequired
+// are required, now that every other field has its fetched value.
+func (r *request) applyConditions() {
+	for _, c := range r.config.conditions {
+		required := c.required(r.configurable)
+		for _, b := range r.bindings {
+			if b.fieldPath != c.fieldPath {
+				continue
+			}
+			if _, ok := r.fetched[b.name]; !required || ok {
+				delete(r.missing, b.name)
+			} else {
+				r.missing[b.name] = struct{}{}
+			}
+		}
+	}
+}
+
+func (r *request) lockApply() {
+	if r.applyLock != nil {
+		r.applyLock.Lock()
+	}
+}
+
+func (r *request) unlockApply() {
+	if r.applyLock != nil {
+		r.applyLock.Unlock()
+	}
+}
+
+func (r *request) apply(name, value string, parseErrors ParseErrors) ParseErrors {
+	applied := true
+	for _, setter := range r.setters[name] {
+		if err := r.set(setter, value); err != nil {
+			parseErrors = append(parseErrors, &ParseError{Name: name, Err: err})
+			applied = false
+		}
+	}
+	if applied {
+		r.resolved[name] = struct{}{}
+		r.values[name] = value
+	}
+	r.fetched[name] = struct{}{}
+	delete(r.missing, name)
+	return parseErrors
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (r *request) set(setter func(string) error, value string) (err error) {
+	if r.config.recoverSetters {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}()
+	}
+	return setter(value)
+}
+
+func (r *request) ParameterNames() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	names := make([]string, 0, len(r.setters))
+	for name := range r.setters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *request) RequiredNames() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return sortedNames(r.required)
+}
+
+func (r *request) OptionalNames() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var names []string
+	for name := range r.setters {
+		if _, ok := r.required[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *request) Resolved() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return sortedNames(r.resolved)
+}
+
+func (r *request) Missing() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return sortedNames(r.missing)
+}
+
+func (r *request) AssertComplete() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return incompleteError(r.missing, r.bindings)
+}
+
+// missingError maps the missing names back to the fields bound to them.
+func (r *request) missingError() error {
+	fields := make(map[string][]string, len(r.missing))
+	for _, b := range r.bindings {
+		if _, ok := r.missing[b.name]; !ok {
+			continue
+		}
+		fieldPath := b.fieldPath
+		if typeName := r.value.Type().Name(); typeName != "" {
+			fieldPath = typeName + "." + fieldPath
+		}
+		fields[b.name] = append(fields[b.name], fieldPath)
+	}
+	return &MissingFieldsError{Missing: sortedNames(r.missing), Fields: fields}
+}
+
+func incompleteError(missing map[string]struct{}, bindings []binding) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	var fields []string
+	described := make(map[string]bool, len(missing))
+	for _, b := range bindings {
+		if _, ok := missing[b.name]; ok {
+			fields = append(fields, fmt.Sprintf("%s (%s)", b.fieldPath, b.name))
+			described[b.name] = true
+		}
+	}
+	names := sortedNames(missing)
+	for _, name := range names {
+		if !described[name] {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return &IncompleteError{Fields: fields, Missing: names}
+}
+
+func (r *request) DescribeBindings() map[string]string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	bindings := make(map[string]string, len(r.bindings))
+	for _, b := range r.bindings {
+		bindings[b.fieldPath] = b.kind
+	}
+	return bindings
+}
+
+func (r *request) Timings() Timings {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	timings := Timings{
+		Pages: append([]time.Duration(nil), r.timings.Pages...),
+		Total: r.timings.Total,
+	}
+	if r.timings.Secure != nil {
+		timings.Secure = make(map[string]time.Duration, len(r.timings.Secure))
+		for name, d := range r.timings.Secure {
+			timings.Secure[name] = d
+		}
+	}
+	return timings
+}
+
+func sortedNames(set map[string]struct{}) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
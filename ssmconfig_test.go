@@ -16,12 +16,74 @@ package ssmconfig
 
 import (
 	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
 )
 
+type fakeClient struct {
+	parameters map[string]string
+	secure     map[string]bool
+	pageSize   int
+}
+
+func (c *fakeClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	prefix := strings.TrimSuffix(aws.ToString(params.Path), "/") + "/"
+	var names []string
+	for name := range c.parameters {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if !aws.ToBool(params.Recursive) && strings.Contains(name[len(prefix):], "/") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	start := 0
+	if params.NextToken != nil {
+		start, _ = strconv.Atoi(*params.NextToken)
+	}
+	pageSize := c.pageSize
+	if pageSize == 0 {
+		pageSize = 10
+	}
+	end := start + pageSize
+	output := ssm.GetParametersByPathOutput{}
+	if end < len(names) {
+		output.NextToken = aws.String(strconv.Itoa(end))
+	} else {
+		end = len(names)
+	}
+	for _, name := range names[start:end] {
+		parameterType := types.ParameterTypeString
+		if c.secure[name] {
+			parameterType = types.ParameterTypeSecureString
+		}
+		output.Parameters = append(output.Parameters, types.Parameter{
+			Name:  aws.String(name),
+			Value: aws.String(c.parameters[name]),
+			Type:  parameterType,
+		})
+	}
+	return &output, nil
+}
+
 type hasTags struct {
 	Foo         string `ssm:"Foo"`
 	OptionalBar string `ssm:"OptionalBar,optional"`
@@ -36,3 +98,826 @@ func TestNewRequest(t *testing.T) {
 	client := ssm.NewFromConfig(cfg)
 	_ = NewRequest(&v, "/HasTags", client)
 }
+
+func (c *fakeClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	name := aws.ToString(params.Name)
+	value, ok := c.parameters[name]
+	if !ok {
+		return nil, &types.ParameterNotFound{}
+	}
+	parameterType := types.ParameterTypeString
+	if c.secure[name] {
+		parameterType = types.ParameterTypeSecureString
+	}
+	return &ssm.GetParameterOutput{Parameter: &types.Parameter{
+		Name:  aws.String(name),
+		Value: aws.String(value),
+		Type:  parameterType,
+	}}, nil
+}
+
+type blockingClient struct{}
+
+func (blockingClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestNewRequestNilPointer(t *testing.T) {
+	var v *hasTags
+	_, err := TryNewRequest(v, "/HasTags", &fakeClient{})
+	if !errors.Is(err, ErrNilConfigurable) {
+		t.Fatalf("expected ErrNilConfigurable, got %v", err)
+	}
+
+	defer func() {
+		if p := recover(); p != ErrNilConfigurable {
+			t.Fatalf("expected panic with ErrNilConfigurable, got %v", p)
+		}
+	}()
+	NewRequest(v, "/HasTags", &fakeClient{})
+}
+
+func TestNewRequestIndirect(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{"/HasTags/Foo": "foo"}}
+
+	var viaInterface interface{} = &hasTags{}
+	if err := NewRequest(&viaInterface, "/HasTags", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v := viaInterface.(*hasTags); v.Foo != "foo" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	var doublePointer *hasTags
+	if err := NewRequest(&doublePointer, "/HasTags", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if doublePointer == nil || doublePointer.Foo != "foo" {
+		t.Fatalf("unexpected result: %+v", doublePointer)
+	}
+
+	var byValue interface{} = hasTags{}
+	var nilInInterface interface{} = (*hasTags)(nil)
+	notStruct := new(string)
+	for _, configurable := range []interface{}{&byValue, &nilInInterface, notStruct} {
+		if _, err := TryNewRequest(configurable, "/HasTags", client); err == nil {
+			t.Errorf("expected error for %T", configurable)
+		}
+	}
+}
+
+func TestTryNewRequestFieldErrors(t *testing.T) {
+	var v struct {
+		Good     string        `ssm:"Good"`
+		hidden   string        `ssm:"hidden"`
+		Events   chan string   `ssm:"Events"`
+		Named    string        `ssm:"Named,self"`
+		Duration time.Duration `ssm:"Duration,base=1"`
+	}
+	_ = v.hidden
+	_, err := TryNewRequest(&v, "/app", &fakeClient{})
+	var fieldErrors FieldErrors
+	if !errors.As(err, &fieldErrors) {
+		t.Fatalf("expected FieldErrors, got %v", err)
+	}
+	var paths []string
+	for _, e := range fieldErrors {
+		paths = append(paths, e.FieldPath)
+	}
+	if !reflect.DeepEqual(paths, []string{"hidden", "Events", "Named", "Duration"}) {
+		t.Fatalf("unexpected field errors: %v", err)
+	}
+	if fieldErrors[1].Type != reflect.TypeOf(v.Events) || !strings.Contains(err.Error(), "Events chan string") {
+		t.Fatalf("expected the type of Events in %v", err)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var v hasTags
+	client := &fakeClient{
+		parameters: map[string]string{
+			"/HasTags/Foo":      "foo",
+			"/HasTags/Other":    "other",
+			"/HasTags/Sub/Deep": "deep",
+			"/Unrelated/Foo":    "unrelated",
+		},
+		pageSize: 1,
+	}
+	req := NewRequest(&v, "/HasTags", client)
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo" || v.OptionalBar != "" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+	if names := req.ParameterNames(); !reflect.DeepEqual(names, []string{"/HasTags/Foo", "/HasTags/OptionalBar"}) {
+		t.Fatalf("unexpected parameter names: %v", names)
+	}
+	if names := req.RequiredNames(); !reflect.DeepEqual(names, []string{"/HasTags/Foo"}) {
+		t.Fatalf("unexpected required names: %v", names)
+	}
+	if names := req.OptionalNames(); !reflect.DeepEqual(names, []string{"/HasTags/OptionalBar"}) {
+		t.Fatalf("unexpected optional names: %v", names)
+	}
+	if resolved := req.Resolved(); !reflect.DeepEqual(resolved, []string{"/HasTags/Foo"}) {
+		t.Fatalf("unexpected resolved names: %v", resolved)
+	}
+	if missing := req.Missing(); len(missing) != 0 {
+		t.Fatalf("unexpected missing names: %v", missing)
+	}
+}
+
+func TestSendMissing(t *testing.T) {
+	var v hasTags
+	client := &fakeClient{parameters: map[string]string{"/HasTags/OptionalBar": "bar"}}
+	req := NewRequest(&v, "/HasTags", client)
+	err := req.Send(context.Background())
+	var missing MissingParameters
+	if !errors.As(err, &missing) || len(missing) != 1 || missing[0] != "/HasTags/Foo" {
+		t.Fatalf("expected missing /HasTags/Foo, got %v", err)
+	}
+	if names := req.Missing(); !reflect.DeepEqual(names, []string{"/HasTags/Foo"}) {
+		t.Fatalf("unexpected missing names: %v", names)
+	}
+	if resolved := req.Resolved(); !reflect.DeepEqual(resolved, []string{"/HasTags/OptionalBar"}) {
+		t.Fatalf("unexpected resolved names: %v", resolved)
+	}
+}
+
+func injectPanic(req Request, name string) {
+	r := req.(*request)
+	r.setters[name] = append([]func(string) error{func(string) error {
+		panic("boom")
+	}}, r.setters[name]...)
+}
+
+func TestWithRecoverSetters(t *testing.T) {
+	client := &fakeClient{
+		parameters: map[string]string{
+			"/HasTags/Foo":         "foo",
+			"/HasTags/OptionalBar": "bar",
+		},
+	}
+
+	var v hasTags
+	req := NewRequest(&v, "/HasTags", client)
+	injectPanic(req, "/HasTags/Foo")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic to propagate by default")
+			}
+		}()
+		_ = req.Send(context.Background())
+	}()
+
+	v = hasTags{}
+	req = NewRequest(&v, "/HasTags", client, WithRecoverSetters())
+	injectPanic(req, "/HasTags/Foo")
+	err := req.Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 1 || parseErrors[0].Name != "/HasTags/Foo" {
+		t.Fatalf("expected parse error for /HasTags/Foo, got %v", err)
+	}
+	if v.OptionalBar != "bar" {
+		t.Fatalf("expected remaining parameters to be applied: %+v", v)
+	}
+}
+
+func TestWithRequestTimeout(t *testing.T) {
+	var v hasTags
+	err := NewRequest(&v, "/HasTags", blockingClient{}, WithRequestTimeout(10*time.Millisecond)).Send(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = NewRequest(&v, "/HasTags", blockingClient{}, WithRequestTimeout(time.Hour)).Send(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > time.Minute {
+		t.Fatalf("expected tighter context deadline to win, got %v", err)
+	}
+}
+
+type eventuallyConsistentClient struct {
+	fakeClient
+	calls   int
+	visible int
+}
+
+func (c *eventuallyConsistentClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	if params.NextToken == nil {
+		c.calls++
+	}
+	if c.calls < c.visible {
+		return &ssm.GetParametersByPathOutput{}, nil
+	}
+	return c.fakeClient.GetParametersByPath(ctx, params, optFns...)
+}
+
+func TestWithMissingRetry(t *testing.T) {
+	client := &eventuallyConsistentClient{
+		fakeClient: fakeClient{parameters: map[string]string{"/HasTags/Foo": "foo"}},
+		visible:    3,
+	}
+	var v hasTags
+	if err := NewRequest(&v, "/HasTags", client, WithMissingRetry(2, time.Millisecond)).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo" || client.calls != 3 {
+		t.Fatalf("unexpected result after %d calls: %+v", client.calls, v)
+	}
+
+	client.calls = 0
+	v = hasTags{}
+	err := NewRequest(&v, "/HasTags", client, WithMissingRetry(1, time.Millisecond)).Send(context.Background())
+	if _, ok := err.(*MissingFieldsError); !ok || client.calls != 2 {
+		t.Fatalf("expected missing parameters after %d calls, got %v", client.calls, err)
+	}
+}
+
+type recordingClient struct {
+	fakeClient
+	inputs []ssm.GetParametersByPathInput
+}
+
+func (c *recordingClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	c.inputs = append(c.inputs, *params)
+	return c.fakeClient.GetParametersByPath(ctx, params, optFns...)
+}
+
+func TestNewRequestWithInput(t *testing.T) {
+	client := &recordingClient{fakeClient: fakeClient{parameters: map[string]string{"/HasTags/Foo": "foo"}}}
+	input := ssm.GetParametersByPathInput{
+		Path:       aws.String("/HasTags/"),
+		MaxResults: aws.Int32(5),
+	}
+	var v hasTags
+	if err := NewRequestWithInput(&v, input, client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+	if len(client.inputs) != 1 || aws.ToInt32(client.inputs[0].MaxResults) != 5 || client.inputs[0].WithDecryption != nil {
+		t.Fatalf("input not passed through as-is: %+v", client.inputs)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic without input.Path")
+			}
+		}()
+		NewRequestWithInput(&v, ssm.GetParametersByPathInput{}, client)
+	}()
+}
+
+func TestWithAfterSend(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/HasTags/Foo":         "foo",
+		"/HasTags/OptionalBar": "bar",
+	}}
+	var v hasTags
+	var derived string
+	derive := WithAfterSend(func(configurable interface{}) error {
+		c := configurable.(*hasTags)
+		derived = c.Foo + "/" + c.OptionalBar
+		return nil
+	})
+	if err := NewRequest(&v, "/HasTags", client, derive).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if derived != "foo/bar" {
+		t.Fatalf("unexpected derived value %q", derived)
+	}
+
+	errDerive := errors.New("derive failed")
+	err := NewRequest(&v, "/HasTags", client, WithAfterSend(func(interface{}) error {
+		return errDerive
+	})).Send(context.Background())
+	if err != errDerive {
+		t.Fatalf("expected callback error, got %v", err)
+	}
+
+	called := false
+	err = NewRequest(&v, "/HasTags", &fakeClient{}, WithAfterSend(func(interface{}) error {
+		called = true
+		return nil
+	})).Send(context.Background())
+	if _, ok := err.(*MissingFieldsError); !ok || called {
+		t.Fatalf("expected missing parameters without callback, got %v (called %v)", err, called)
+	}
+}
+
+type staticClient []types.Parameter
+
+func (c staticClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	return &ssm.GetParametersByPathOutput{Parameters: c}, nil
+}
+
+func TestSendNormalizesNames(t *testing.T) {
+	client := staticClient{
+		{Name: aws.String("HasTags/Foo"), Value: aws.String("foo")},
+		{Name: aws.String("//HasTags/OptionalBar/"), Value: aws.String("bar")},
+	}
+	var v hasTags
+	if err := NewRequest(&v, "HasTags/", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo" || v.OptionalBar != "bar" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	var root struct {
+		Foo string `ssm:"/Foo/"`
+	}
+	req := NewRequest(&root, "/", staticClient{{Name: aws.String("Foo"), Value: aws.String("foo")}})
+	if names := req.ParameterNames(); len(names) != 1 || names[0] != "/Foo" {
+		t.Fatalf("unexpected parameter names: %v", names)
+	}
+	if err := req.Send(context.Background()); err != nil || root.Foo != "foo" {
+		t.Fatalf("unexpected result: %+v, %v", root, err)
+	}
+}
+
+func TestWithPathFunc(t *testing.T) {
+	client := &recordingClient{fakeClient: fakeClient{parameters: map[string]string{
+		"/us-west-2/HasTags/Foo": "foo",
+	}}}
+	var v hasTags
+	req := NewRequest(&v, "/HasTags", client, WithPathFunc(func(ctx context.Context) (string, error) {
+		return "us-west-2/HasTags/", nil
+	}))
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo" || aws.ToString(client.inputs[0].Path) != "/us-west-2/HasTags" {
+		t.Fatalf("unexpected result %+v fetching %+v", v, client.inputs)
+	}
+	if names := req.ParameterNames(); !reflect.DeepEqual(names, []string{"/us-west-2/HasTags/Foo", "/us-west-2/HasTags/OptionalBar"}) {
+		t.Fatalf("unexpected parameter names: %v", names)
+	}
+
+	errResolve := errors.New("no region")
+	err := NewRequest(&v, "/HasTags", client, WithPathFunc(func(ctx context.Context) (string, error) {
+		return "", errResolve
+	})).Send(context.Background())
+	if err != errResolve {
+		t.Fatalf("expected path func error, got %v", err)
+	}
+}
+
+func TestWithTiming(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/HasTags/Foo":         "foo",
+		"/HasTags/OptionalBar": "bar",
+	}, pageSize: 1}
+	var v hasTags
+	req := NewRequest(&v, "/HasTags", client, WithTiming())
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	timings := req.Timings()
+	if len(timings.Pages) != 2 || timings.Total <= 0 || timings.Total < timings.Pages[0]+timings.Pages[1] {
+		t.Fatalf("unexpected timings: %+v", timings)
+	}
+
+	req = NewRequest(&v, "/HasTags", client)
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if timings := req.Timings(); len(timings.Pages) != 0 || timings.Total != 0 {
+		t.Fatalf("expected no timings without WithTiming: %+v", timings)
+	}
+}
+
+func TestWithConditionalRequired(t *testing.T) {
+	type database struct {
+		Password    string `ssm:"Password,optional"`
+		UsesIAMAuth string `ssm:"UsesIAMAuth,optional"`
+	}
+	passwordUnlessIAM := WithConditionalRequired("Password", func(configurable interface{}) bool {
+		return configurable.(*database).UsesIAMAuth != "true"
+	})
+
+	var v database
+	client := &fakeClient{parameters: map[string]string{"/db/UsesIAMAuth": "true"}}
+	if err := NewRequest(&v, "/db", client, passwordUnlessIAM).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	v = database{}
+	client.parameters["/db/UsesIAMAuth"] = "false"
+	err := NewRequest(&v, "/db", client, passwordUnlessIAM).Send(context.Background())
+	var missing MissingParameters
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/db/Password"}) {
+		t.Fatalf("expected missing /db/Password, got %v", err)
+	}
+
+	client.parameters["/db/Password"] = "hunter2"
+	if err := NewRequest(&v, "/db", client, passwordUnlessIAM).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var required hasTags
+	err = NewRequest(&required, "/HasTags", &fakeClient{}, WithConditionalRequired("Foo", func(interface{}) bool {
+		return false
+	})).Send(context.Background())
+	if err != nil {
+		t.Fatalf("expected condition to make Foo optional, got %v", err)
+	}
+
+	_, err = TryNewRequest(&required, "/HasTags", &fakeClient{}, WithConditionalRequired("Typo", func(interface{}) bool {
+		return true
+	}))
+	if err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+}
+
+type pathFetcherFunc func(ctx context.Context, params *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error)
+
+func (f pathFetcherFunc) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	return f(ctx, params)
+}
+
+func TestPathFetcher(t *testing.T) {
+	var paths []string
+	fetcher := pathFetcherFunc(func(ctx context.Context, params *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
+		paths = append(paths, aws.ToString(params.Path))
+		return &ssm.GetParametersByPathOutput{Parameters: []types.Parameter{
+			{Name: aws.String("/HasTags/Foo"), Value: aws.String("foo")},
+		}}, nil
+	})
+
+	var v hasTags
+	if err := NewRequest(&v, "/HasTags", fetcher).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo" || !reflect.DeepEqual(paths, []string{"/HasTags"}) {
+		t.Fatalf("unexpected result: %+v, paths %v", v, paths)
+	}
+}
+
+func TestWithRawPageObserver(t *testing.T) {
+	client := &fakeClient{
+		parameters: map[string]string{"/HasTags/Foo": "foo", "/HasTags/OptionalBar": "bar"},
+		pageSize:   1,
+	}
+	var v hasTags
+	var observed []string
+	req := NewRequest(&v, "/HasTags", client, WithRawPageObserver(func(page *ssm.GetParametersByPathOutput) {
+		for _, p := range page.Parameters {
+			observed = append(observed, aws.ToString(p.Name))
+		}
+		if len(observed) == 1 && v.Foo != "" {
+			t.Error("page observed after it was applied")
+		}
+	}))
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(observed) != 2 {
+		t.Fatalf("expected 2 pages of 1 parameter, got %v", observed)
+	}
+}
+
+func TestAddParameter(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/HasTags/Foo":    "foo",
+		"/HasTags/Extra":  "extra",
+		"/shared/feature": "on",
+	}}
+	var v hasTags
+	var extra, feature string
+	req := NewRequest(&v, "/HasTags", client)
+	req.AddParameter("/HasTags/Extra", func(value string) { extra = value })
+	req.AddParameter("shared/feature/", func(value string) { feature = value })
+	if names := req.ParameterNames(); !reflect.DeepEqual(names, []string{"/HasTags/Extra", "/HasTags/Foo", "/HasTags/OptionalBar", "/shared/feature"}) {
+		t.Fatalf("unexpected parameter names: %v", names)
+	}
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if extra != "extra" || feature != "on" || v.Foo != "foo" {
+		t.Fatalf("unexpected result: %q, %q, %+v", extra, feature, v)
+	}
+	expectPanic(t, func() { req.AddParameter("/HasTags/Late", func(string) {}) })
+
+	req = NewRequest(&v, "/HasTags", client)
+	req.AddParameter("/HasTags/Absent", func(string) {})
+	err := req.Send(context.Background())
+	var missing MissingParameters
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/HasTags/Absent"}) {
+		t.Fatalf("expected /HasTags/Absent to be missing, got %v", err)
+	}
+
+	expectPanic(t, func() {
+		NewRequest(&v, "/HasTags", staticClient{}).AddParameter("/shared/feature", func(string) {})
+	})
+}
+
+// cancelingClient cancels the context given to Send once it has been called
+// after times, as if the caller gave up mid-Send.
+type cancelingClient struct {
+	fakeClient
+	cancel func()
+	after  int
+	calls  int
+}
+
+func (c *cancelingClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	if c.calls++; c.calls > c.after {
+		c.cancel()
+	}
+	return c.fakeClient.GetParametersByPath(ctx, params, optFns...)
+}
+
+func TestCanceledError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &cancelingClient{
+		fakeClient: fakeClient{
+			parameters: map[string]string{"/HasTags/Foo": "foo", "/HasTags/OptionalBar": "bar"},
+			pageSize:   1,
+		},
+		cancel: cancel,
+		after:  1,
+	}
+	var v struct {
+		Foo string `ssm:"Foo"`
+		Bar string `ssm:"OptionalBar"`
+	}
+	err := NewRequest(&v, "/HasTags", client).Send(ctx)
+	var canceled *CanceledError
+	if !errors.As(err, &canceled) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected CanceledError wrapping context.Canceled, got %v", err)
+	}
+	if !reflect.DeepEqual(canceled.Applied, []string{"/HasTags/Foo"}) || !reflect.DeepEqual(canceled.Missing, []string{"/HasTags/OptionalBar"}) || v.Foo != "foo" {
+		t.Fatalf("unexpected partial result: %+v, %+v", canceled, v)
+	}
+
+	errDenied := errors.New("access denied")
+	err = NewRequest(&v, "/HasTags", staticErrorClient{errDenied}).Send(context.Background())
+	if errors.As(err, &canceled) || !errors.Is(err, errDenied) {
+		t.Fatalf("expected other errors as-is, got %v", err)
+	}
+}
+
+type staticErrorClient struct {
+	err error
+}
+
+func (c staticErrorClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	return nil, c.err
+}
+
+// observingLocker is a sync.Locker that records what the configurable held
+// each time it was locked.
+type observingLocker struct {
+	sync.Mutex
+	observe func()
+}
+
+func (l *observingLocker) Lock() {
+	l.Mutex.Lock()
+	l.observe()
+}
+
+func TestWithApplyLock(t *testing.T) {
+	client := &fakeClient{
+		parameters: map[string]string{"/HasTags/Foo": "foo", "/HasTags/OptionalBar": "bar"},
+		pageSize:   1,
+	}
+	var v hasTags
+	var locked []hasTags
+	var pages int
+	lock := &observingLocker{observe: func() { locked = append(locked, v) }}
+	req := NewRequest(&v, "/HasTags", client, WithApplyLock(lock), WithRawPageObserver(func(*ssm.GetParametersByPathOutput) {
+		pages++
+	}))
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if pages != 2 || len(locked) != 1 || locked[0] != (hasTags{}) || v.OptionalBar != "bar" {
+		t.Fatalf("expected both pages applied under one lock, got %d pages, %+v", pages, locked)
+	}
+}
+
+func TestAssertComplete(t *testing.T) {
+	var v struct {
+		Foo      string `ssm:"Foo"`
+		URL      string `ssm:"url"`
+		Optional string `ssm:"Optional,optional"`
+	}
+	client := &fakeClient{parameters: map[string]string{"/app/Foo": "foo"}}
+	req := NewRequest(&v, "/app", client)
+	req.AddParameter("/shared/flag", func(string) {})
+	_ = req.Send(context.Background())
+
+	err := req.AssertComplete()
+	var incomplete *IncompleteError
+	if !errors.As(err, &incomplete) {
+		t.Fatalf("expected an *IncompleteError, got %v", err)
+	}
+	if want := []string{"/shared/flag", "URL (/app/url)"}; !reflect.DeepEqual(incomplete.Fields, want) {
+		t.Errorf("got fields %v, want %v", incomplete.Fields, want)
+	}
+	var missing MissingParameters
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/app/url", "/shared/flag"}) {
+		t.Errorf("got missing %v", missing)
+	}
+
+	client.parameters["/app/url"] = "postgres://db"
+	client.parameters["/shared/flag"] = "on"
+	req = NewRequest(&v, "/app", client)
+	req.AddParameter("/shared/flag", func(string) {})
+	if err := req.AssertComplete(); err == nil {
+		t.Error("expected an error before Send")
+	}
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := req.AssertComplete(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMissingFieldsError(t *testing.T) {
+	type Config struct {
+		DBHost  string `ssm:"db/host"`
+		Primary string `ssm:"replica"`
+		Replica string `ssm:"replica"`
+	}
+	var v Config
+	req := NewRequestWithInput(&v, ssm.GetParametersByPathInput{Path: aws.String("/app"), Recursive: aws.Bool(true)}, &fakeClient{})
+	req.AddParameter("/shared/flag", func(string) {})
+	err := req.Send(context.Background())
+	var missing *MissingFieldsError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expected a *MissingFieldsError, got %v", err)
+	}
+	want := "missing ssm parameters: /app/db/host (field Config.DBHost), /app/replica (fields Config.Primary, Config.Replica), /shared/flag"
+	if err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+	var names MissingParameters
+	if !errors.As(err, &names) || !reflect.DeepEqual([]string(names), []string{"/app/db/host", "/app/replica", "/shared/flag"}) {
+		t.Errorf("got missing %v", names)
+	}
+
+	var anonymous struct {
+		Foo string `ssm:"Foo"`
+	}
+	err = NewRequest(&anonymous, "/app", &fakeClient{}).Send(context.Background())
+	if want := "missing ssm parameters: /app/Foo (field Foo)"; err == nil || err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+}
+
+func TestSendTwice(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{"/HasTags/Foo": "foo"}}
+	var v hasTags
+	req := NewRequest(&v, "/HasTags", client)
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	client.parameters["/HasTags/Foo"] = "changed"
+	if err := req.Send(context.Background()); !errors.Is(err, ErrAlreadySent) {
+		t.Fatalf("expected ErrAlreadySent, got %v", err)
+	}
+	if v.Foo != "foo" {
+		t.Errorf("second Send set Foo to %q", v.Foo)
+	}
+
+	merged := MergeRequests([]Request{NewRequest(&v, "/HasTags", client)})
+	if err := merged.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := merged.Send(context.Background()); !errors.Is(err, ErrAlreadySent) {
+		t.Fatalf("expected ErrAlreadySent from a merged request, got %v", err)
+	}
+}
+
+func TestWithDeprecationHook(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{"/app/OldKey": "old", "/app/NewKey": "new"}}
+	var v struct {
+		Old    string `ssm:"OldKey,optional,deprecated='use NewKey, or else'"`
+		New    string `ssm:"NewKey"`
+		Absent string `ssm:"Absent,optional,deprecated=gone"`
+	}
+	var warnings []string
+	hook := WithDeprecationHook(func(name, message string) {
+		warnings = append(warnings, name+": "+message)
+	})
+	if err := NewRequest(&v, "/app", client, hook).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/app/OldKey: use NewKey, or else"}; !reflect.DeepEqual(warnings, want) {
+		t.Errorf("got warnings %v, want %v", warnings, want)
+	}
+	if v.Old != "old" {
+		t.Errorf("got Old %q, want old", v.Old)
+	}
+}
+
+func TestSet(t *testing.T) {
+	var v struct {
+		Foo   string `ssm:"Foo"`
+		Port  int    `ssm:"Port"`
+		Level string `ssm:"Level,default=info"`
+	}
+	req := NewRequest(&v, "/app", nil)
+	if err := req.Set("Foo", "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := req.Set("Port", "not a number"); err == nil {
+		t.Error("expected a parse error")
+	}
+	if err := req.Set("Absent", "x"); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+	var missing MissingParameters
+	if err := req.Send(context.Background()); !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/app/Port"}) {
+		t.Fatalf("expected /app/Port missing, got %v", err)
+	}
+
+	req = NewRequest(&v, "/app", nil)
+	for _, s := range [][2]string{{"Foo", "foo"}, {"Port", "8080"}, {"Level", "debug"}} {
+		if err := req.Set(s[0], s[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo" || v.Port != 8080 || v.Level != "debug" {
+		t.Errorf("unexpected result: %+v", v)
+	}
+	if resolved := req.Resolved(); !reflect.DeepEqual(resolved, []string{"/app/Foo", "/app/Level", "/app/Port"}) {
+		t.Errorf("unexpected resolved names: %v", resolved)
+	}
+	if err := req.Set("Foo", "late"); err == nil {
+		t.Error("expected an error after Send")
+	}
+
+	var w struct {
+		A string `ssm:"A"`
+		B string `ssm:"B"`
+	}
+	client := &fakeClient{parameters: map[string]string{"/late/B": "b"}}
+	req = NewRequest(&w, "/app", client, WithPathFunc(func(context.Context) (string, error) { return "/late", nil }))
+	if err := req.Set("A", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if w.A != "a" || w.B != "b" {
+		t.Errorf("unexpected result: %+v", w)
+	}
+	if resolved := req.Resolved(); !reflect.DeepEqual(resolved, []string{"/late/A", "/late/B"}) {
+		t.Errorf("unexpected resolved names: %v", resolved)
+	}
+}
+
+func TestRegistrationAfterSend(t *testing.T) {
+	var v hasTags
+	req := NewRequest(&v, "/HasTags", blockingClient{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- req.Send(ctx)
+	}()
+	for !req.(*request).frozen.Load() {
+		runtime.Gosched()
+	}
+
+	// Send is still fetching, holding the lock.
+	expectPanic(t, func() { req.AddParameter("/HasTags/Extra", func(string) {}) })
+	if err := req.Set("Foo", "foo"); !errors.Is(err, ErrAlreadySent) {
+		t.Errorf("expected ErrAlreadySent from Set during Send, got %v", err)
+	}
+	cancel()
+	<-done
+	expectPanic(t, func() { req.AddParameter("/HasTags/Extra", func(string) {}) })
+	if err := req.Set("Foo", "foo"); !errors.Is(err, ErrAlreadySent) {
+		t.Errorf("expected ErrAlreadySent from Set after Send, got %v", err)
+	}
+	if names := req.ParameterNames(); !reflect.DeepEqual(names, []string{"/HasTags/Foo", "/HasTags/OptionalBar"}) {
+		t.Errorf("expected no parameter to be added, got %v", names)
+	}
+
+	merged := MergeRequests([]Request{NewRequest(&hasTags{}, "/HasTags", &fakeClient{})})
+	_ = merged.Send(context.Background())
+	expectPanic(t, func() { merged.AddParameter("/HasTags/Extra", func(string) {}) })
+	if err := merged.Set("Foo", "foo"); !errors.Is(err, ErrAlreadySent) {
+		t.Errorf("expected ErrAlreadySent from a merged Set after Send, got %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ssmconfigtest provides a fake SSM client for testing code that
+// loads its configuration with ssmconfig, including how it handles errors,
+// throttling and timeouts, without AWS.
+package ssmconfigtest
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
+	"github.com/retailnext/ssmconfig"
+)
+
+// DefaultPageSize is the number of parameters per page until SetPageSize.
+const DefaultPageSize = 10
+
+// Client is a fake of the parts of *ssm.Client ssmconfig uses to read
+// parameters. It serves the parameters given to Put by path as
+// GetParametersByPath does, in pages ordered by name, and by name as
+// GetParameter and GetParameters do. Every call returns the error of ctx
+// once it's done. Its methods are safe for concurrent use.
+type Client struct {
+	lock       sync.Mutex
+	parameters map[string]types.Parameter
+	pageSize   int
+	latency    time.Duration
+	failures   map[int][]error
+	inputs     []ssm.GetParametersByPathInput
+}
+
+var (
+	_ ssmconfig.PathFetcher            = (*Client)(nil)
+	_ ssmconfig.GetParameterAPIClient  = (*Client)(nil)
+	_ ssmconfig.GetParametersAPIClient = (*Client)(nil)
+)
+
+// New returns an empty Client.
+func New() *Client {
+	return &Client{
+		parameters: make(map[string]types.Parameter),
+		pageSize:   DefaultPageSize,
+		failures:   make(map[int][]error),
+	}
+}
+
+// Put sets the String parameter name to value.
+func (c *Client) Put(name, value string) {
+	c.put(name, value, types.ParameterTypeString)
+}
+
+// PutSecure sets the SecureString parameter name to value.
+func (c *Client) PutSecure(name, value string) {
+	c.put(name, value, types.ParameterTypeSecureString)
+}
+
+func (c *Client) put(name, value string, parameterType types.ParameterType) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	version := c.parameters[name].Version + 1
+	c.parameters[name] = types.Parameter{
+		Name:    aws.String(name),
+		Value:   aws.String(value),
+		Type:    parameterType,
+		Version: version,
+	}
+}
+
+// Delete deletes the parameter name.
+func (c *Client) Delete(name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.parameters, name)
+}
+
+// SetPageSize sets the number of parameters per page of
+// GetParametersByPath, unless a call asks for fewer.
+func (c *Client) SetPageSize(n int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.pageSize = n
+}
+
+// SetLatency makes every call wait d, or until ctx is done, before it
+// answers.
+func (c *Client) SetLatency(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.latency = d
+}
+
+// FailPage makes the next call of GetParametersByPath for the given page,
+// counting from 0, return err instead. Errors queued for the same page are
+// returned by successive calls, in order.
+func (c *Client) FailPage(page int, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.failures[page] = append(c.failures[page], err)
+}
+
+// ThrottlePage makes the next times calls of GetParametersByPath for the
+// given page fail as SSM does when it throttles.
+func (c *Client) ThrottlePage(page, times int) {
+	for i := 0; i < times; i++ {
+		c.FailPage(page, ThrottlingError())
+	}
+}
+
+// ThrottlingError returns the error SSM returns for a call it throttled.
+func ThrottlingError() error {
+	return &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded", Fault: smithy.FaultClient}
+}
+
+// Inputs returns the input of every call of GetParametersByPath, in order,
+// including those that failed.
+func (c *Client) Inputs() []ssm.GetParametersByPathInput {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]ssm.GetParametersByPathInput(nil), c.inputs...)
+}
+
+func (c *Client) wait(ctx context.Context) error {
+	c.lock.Lock()
+	latency := c.latency
+	c.lock.Unlock()
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+	}
+	return ctx.Err()
+}
+
+func (c *Client) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	c.lock.Lock()
+	c.inputs = append(c.inputs, *params)
+	c.lock.Unlock()
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	pageSize := c.pageSize
+	if n := int(aws.ToInt32(params.MaxResults)); n > 0 && n < pageSize {
+		pageSize = n
+	}
+	start := 0
+	if params.NextToken != nil {
+		var err error
+		if start, err = strconv.Atoi(*params.NextToken); err != nil {
+			return nil, &smithy.GenericAPIError{Code: "InvalidNextToken", Message: "invalid next token", Fault: smithy.FaultClient}
+		}
+	}
+	page := start / pageSize
+	if errs := c.failures[page]; len(errs) > 0 {
+		c.failures[page] = errs[1:]
+		return nil, errs[0]
+	}
+
+	prefix := strings.TrimSuffix(aws.ToString(params.Path), "/") + "/"
+	var names []string
+	for name := range c.parameters {
+		rest := strings.TrimPrefix(name, prefix)
+		if rest == name || !aws.ToBool(params.Recursive) && strings.Contains(rest, "/") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var output ssm.GetParametersByPathOutput
+	end := start + pageSize
+	if end < len(names) {
+		output.NextToken = aws.String(strconv.Itoa(end))
+	} else {
+		end = len(names)
+	}
+	for i := start; i < end; i++ {
+		output.Parameters = append(output.Parameters, c.parameters[names[i]])
+	}
+	return &output, nil
+}
+
+func (c *Client) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	parameter, ok := c.parameters[aws.ToString(params.Name)]
+	if !ok {
+		return nil, &types.ParameterNotFound{Message: aws.String("parameter not found")}
+	}
+	return &ssm.GetParameterOutput{Parameter: &parameter}, nil
+}
+
+func (c *Client) GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var output ssm.GetParametersOutput
+	for _, name := range params.Names {
+		if parameter, ok := c.parameters[name]; ok {
+			output.Parameters = append(output.Parameters, parameter)
+		} else {
+			output.InvalidParameters = append(output.InvalidParameters, name)
+		}
+	}
+	return &output, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfigtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/retailnext/ssmconfig"
+)
+
+type config struct {
+	A string `ssm:"A"`
+	B string `ssm:"B"`
+	C string `ssm:"C"`
+}
+
+func newClient() *Client {
+	c := New()
+	c.Put("/app/A", "a")
+	c.Put("/app/B", "b")
+	c.PutSecure("/app/C", "c")
+	c.SetPageSize(1)
+	return c
+}
+
+func TestClient(t *testing.T) {
+	client := newClient()
+	var v config
+	if err := ssmconfig.NewRequest(&v, "/app", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v != (config{A: "a", B: "b", C: "c"}) {
+		t.Errorf("unexpected result: %+v", v)
+	}
+	if n := len(client.Inputs()); n != 3 {
+		t.Errorf("got %d pages, want 3", n)
+	}
+}
+
+func TestFailPage(t *testing.T) {
+	client := newClient()
+	boom := errors.New("boom")
+	client.FailPage(1, boom)
+	var v config
+	if err := ssmconfig.NewRequest(&v, "/app", client).Send(context.Background()); !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+	if v.A != "a" || v.B != "" {
+		t.Errorf("expected only the first page applied, got %+v", v)
+	}
+}
+
+func TestThrottlePage(t *testing.T) {
+	client := newClient()
+	client.ThrottlePage(2, 2)
+	var v config
+	if err := ssmconfig.NewRequest(&v, "/app", client, ssmconfig.WithThrottleRetry(2, time.Millisecond)).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(client.Inputs()); n != 5 {
+		t.Errorf("got %d calls, want 5", n)
+	}
+
+	client.ThrottlePage(0, 1)
+	if err := ssmconfig.NewRequest(&v, "/app", client, ssmconfig.WithThrottleRetry(0, 0)).Send(context.Background()); err == nil {
+		t.Error("expected the throttling error without retries")
+	}
+}
+
+func TestSetLatency(t *testing.T) {
+	client := newClient()
+	client.SetLatency(time.Hour)
+	var v config
+	err := ssmconfig.NewRequest(&v, "/app", client, ssmconfig.WithRequestTimeout(10*time.Millisecond)).Send(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to be exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ssmotel traces the Send of ssmconfig requests with OpenTelemetry,
+// without making the root package depend on it.
+package ssmotel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/retailnext/ssmconfig"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/retailnext/ssmconfig/ssmotel"
+
+// The attributes set on the spans. None holds a parameter value.
+const (
+	PathKey       = attribute.Key("ssmconfig.path")
+	ParametersKey = attribute.Key("ssmconfig.parameters")
+	PagesKey      = attribute.Key("ssmconfig.pages")
+	PageKey       = attribute.Key("ssmconfig.page")
+)
+
+// WithTracing makes Send run in an "ssmconfig.Send" span, with a child
+// "ssmconfig.NextPage" span for each page fetched by path. The Send span
+// records the base path along with the number of parameters fetched and
+// pages, and each page span its number and the number of parameters on it.
+// Errors are recorded on the span that returned them, those that fail to
+// parse a parameter by their type and the names of the parameters only, as
+// their messages quote the values. It uses the global TracerProvider if tp
+// is nil.
+func WithTracing(tp trace.TracerProvider) ssmconfig.Option {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return ssmconfig.WithTracer(tracer{tp.Tracer(instrumentationName)})
+}
+
+type tracer struct {
+	tracer trace.Tracer
+}
+
+func (t tracer) StartSend(ctx context.Context) (context.Context, func(ssmconfig.SendTrace, error)) {
+	ctx, span := t.tracer.Start(ctx, "ssmconfig.Send")
+	return ctx, func(st ssmconfig.SendTrace, err error) {
+		span.SetAttributes(
+			PathKey.String(st.Path),
+			ParametersKey.Int(st.Parameters),
+			PagesKey.Int(st.Pages),
+		)
+		end(span, err)
+	}
+}
+
+func (t tracer) StartPage(ctx context.Context, page int) (context.Context, func(int, error)) {
+	ctx, span := t.tracer.Start(ctx, "ssmconfig.NextPage", trace.WithAttributes(PageKey.Int(page)))
+	return ctx, func(parameters int, err error) {
+		if err == nil {
+			span.SetAttributes(ParametersKey.Int(parameters))
+		}
+		end(span, err)
+	}
+}
+
+// The attributes of the exception event recorded for an error, as
+// span.RecordError sets them.
+const (
+	exceptionTypeKey    = attribute.Key("exception.type")
+	exceptionMessageKey = attribute.Key("exception.message")
+)
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		message := describe(err)
+		span.AddEvent("exception", trace.WithAttributes(
+			exceptionTypeKey.String(fmt.Sprintf("%T", err)),
+			exceptionMessageKey.String(message),
+		))
+		span.SetStatus(codes.Error, message)
+	}
+	span.End()
+}
+
+// describe returns the message of err, unless it holds a ParseError, whose
+// message may hold the value that failed to parse: then it returns the type
+// of err and the names of the parameters.
+func describe(err error) string {
+	var names []string
+	var parseErrors ssmconfig.ParseErrors
+	var parseError *ssmconfig.ParseError
+	switch {
+	case errors.As(err, &parseErrors):
+		for _, e := range parseErrors {
+			names = append(names, e.Name)
+		}
+	case errors.As(err, &parseError):
+		names = append(names, parseError.Name)
+	default:
+		return err.Error()
+	}
+	return fmt.Sprintf("%T: invalid ssm parameters: %s", err, strings.Join(names, ", "))
+}
@@ -0,0 +1,158 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmotel
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/retailnext/ssmconfig"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// pagingClient returns one parameter per page, then fails with err.
+type pagingClient struct {
+	names []string
+	err   error
+}
+
+func (c pagingClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	i := 0
+	if params.NextToken != nil {
+		i = len(*params.NextToken)
+	}
+	if i == len(c.names) {
+		return nil, c.err
+	}
+	output := ssm.GetParametersByPathOutput{
+		Parameters: []types.Parameter{{Name: aws.String(c.names[i]), Value: aws.String("secret value")}},
+	}
+	if i+1 < len(c.names) || c.err != nil {
+		output.NextToken = aws.String(strings.Repeat(".", i+1))
+	}
+	return &output, nil
+}
+
+type config struct {
+	Host string `ssm:"Host"`
+	Port string `ssm:"Port"`
+}
+
+func TestWithTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := pagingClient{names: []string{"/app/Host", "/app/Port"}}
+	var v config
+	if err := ssmconfig.NewRequest(&v, "/app", client, WithTracing(tp)).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	send := spans[2]
+	if send.Name() != "ssmconfig.Send" || send.Status().Code == codes.Error {
+		t.Fatalf("unexpected send span: %s %v", send.Name(), send.Status())
+	}
+	expectAttributes(t, send.Attributes(), PathKey.String("/app"), ParametersKey.Int(2), PagesKey.Int(2))
+	for i, page := range spans[:2] {
+		if page.Name() != "ssmconfig.NextPage" || page.Parent().SpanID() != send.SpanContext().SpanID() {
+			t.Fatalf("unexpected page span: %s", page.Name())
+		}
+		expectAttributes(t, page.Attributes(), PageKey.Int(i), ParametersKey.Int(1))
+	}
+	for _, span := range spans {
+		for _, kv := range span.Attributes() {
+			if kv.Value.AsString() == "secret value" {
+				t.Fatalf("span %s records a value", span.Name())
+			}
+		}
+	}
+}
+
+func TestWithTracingError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := pagingClient{names: []string{"/app/Host"}, err: errors.New("page failed")}
+	var v config
+	if err := ssmconfig.NewRequest(&v, "/app", client, WithTracing(tp)).Send(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	if spans[0].Status().Code == codes.Error {
+		t.Fatal("expected the first page to succeed")
+	}
+	for _, span := range spans[1:] {
+		if span.Status().Code != codes.Error || len(span.Events()) != 1 {
+			t.Fatalf("expected span %s to record the error", span.Name())
+		}
+	}
+	expectAttributes(t, spans[2].Attributes(), PathKey.String("/app"), ParametersKey.Int(1), PagesKey.Int(2))
+}
+
+func expectAttributes(t *testing.T, attributes []attribute.KeyValue, expected ...attribute.KeyValue) {
+	t.Helper()
+	set := attribute.NewSet(attributes...)
+	for _, kv := range expected {
+		if value, ok := set.Value(kv.Key); !ok || value != kv.Value {
+			t.Errorf("expected %s=%s, got %v", kv.Key, kv.Value.Emit(), attributes)
+		}
+	}
+}
+
+func TestWithTracingParseError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := pagingClient{names: []string{"/app/Host", "/app/Port"}}
+	var v struct {
+		Host string `ssm:"Host"`
+		Port int    `ssm:"Port"`
+	}
+	err := ssmconfig.NewRequest(&v, "/app", client, WithTracing(tp)).Send(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "secret value") {
+		t.Fatalf("expected a parse error quoting the value, got %v", err)
+	}
+
+	spans := recorder.Ended()
+	send := spans[len(spans)-1]
+	if send.Status().Code != codes.Error || !strings.Contains(send.Status().Description, "/app/Port") || len(send.Events()) != 1 {
+		t.Fatalf("expected the send span to record the error: %v", send.Status())
+	}
+	for _, span := range spans {
+		if strings.Contains(span.Status().Description, "secret value") {
+			t.Fatalf("span %s records a value in its status", span.Name())
+		}
+		for _, event := range span.Events() {
+			for _, kv := range event.Attributes {
+				if strings.Contains(kv.Value.Emit(), "secret value") {
+					t.Fatalf("span %s records a value in its %s event", span.Name(), event.Name)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ssmsecretsmanager lets ssmconfig fetch some fields from AWS
+// Secrets Manager, without making the root package depend on it.
+package ssmsecretsmanager
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/retailnext/ssmconfig"
+)
+
+// Modifier is the ssm tag modifier that marks a field as a secret, e.g.
+// `ssm:"DbPassword,secretsmanager"`, whose ID is then the name the field
+// would have in SSM, or `ssm:"DbPassword,secretsmanager=prod/db"`.
+const Modifier = "secretsmanager"
+
+// APIClient is the subset of *secretsmanager.Client used to fetch secrets.
+type APIClient interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+var _ APIClient = (*secretsmanager.Client)(nil)
+
+// WithSecretsManagerClient fetches fields tagged with Modifier from the
+// current version of their secret with client, one GetSecretValue call
+// each, while the rest come from SSM. A secret that doesn't exist is
+// reported like a missing parameter. A binary secret sets its field to the
+// raw bytes.
+func WithSecretsManagerClient(client APIClient) ssmconfig.Option {
+	return ssmconfig.WithSource(Modifier, func(ctx context.Context, id string) (string, bool, error) {
+		output, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)})
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		if output.SecretString != nil {
+			return *output.SecretString, true, nil
+		}
+		return string(output.SecretBinary), true, nil
+	})
+}
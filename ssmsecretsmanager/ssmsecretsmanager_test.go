@@ -0,0 +1,67 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmsecretsmanager
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/retailnext/ssmconfig"
+	"github.com/retailnext/ssmconfig/ssmconfigtest"
+)
+
+type fakeSecrets map[string]string
+
+func (f fakeSecrets) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	value, ok := f[aws.ToString(params.SecretId)]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("not found")}
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(value)}, nil
+}
+
+func TestWithSecretsManagerClient(t *testing.T) {
+	client := ssmconfigtest.New()
+	client.Put("/app/Host", "db.internal")
+	client.Put("/app/Password", "not the secret")
+	secrets := fakeSecrets{"/app/Password": "hunter2", "prod/token": "t0ken"}
+
+	var v struct {
+		Host     string `ssm:"Host"`
+		Password string `ssm:"Password,secretsmanager"`
+		Token    string `ssm:"Token,secretsmanager=prod/token"`
+	}
+	req := ssmconfig.NewRequest(&v, "/app", client, WithSecretsManagerClient(secrets))
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Host != "db.internal" || v.Password != "hunter2" || v.Token != "t0ken" {
+		t.Errorf("unexpected result: %+v", v)
+	}
+
+	var w struct {
+		Absent string `ssm:"Absent,secretsmanager"`
+	}
+	err := ssmconfig.NewRequest(&w, "/app", client, WithSecretsManagerClient(secrets)).Send(context.Background())
+	var missing ssmconfig.MissingParameters
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/app/Absent"}) {
+		t.Errorf("expected /app/Absent missing, got %v", err)
+	}
+}
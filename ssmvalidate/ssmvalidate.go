@@ -0,0 +1,33 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ssmvalidate runs go-playground/validator on the structs loaded by
+// ssmconfig, without making the root package depend on it.
+package ssmvalidate
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/retailnext/ssmconfig"
+)
+
+// WithValidation makes Send validate the configurable by its validate tags
+// once every parameter has been applied, returning the
+// validator.ValidationErrors, if any. It uses validator.New() if validate
+// is nil.
+func WithValidation(validate *validator.Validate) ssmconfig.Option {
+	if validate == nil {
+		validate = validator.New()
+	}
+	return ssmconfig.WithAfterSend(validate.Struct)
+}
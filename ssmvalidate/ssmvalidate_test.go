@@ -0,0 +1,57 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmvalidate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/go-playground/validator/v10"
+	"github.com/retailnext/ssmconfig"
+)
+
+type staticClient map[string]string
+
+func (c staticClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	var output ssm.GetParametersByPathOutput
+	for name, value := range c {
+		output.Parameters = append(output.Parameters, types.Parameter{Name: aws.String(name), Value: aws.String(value)})
+	}
+	return &output, nil
+}
+
+type config struct {
+	Email string `ssm:"Email" validate:"email"`
+	Port  int    `ssm:"Port" validate:"min=1,max=65535"`
+}
+
+func TestWithValidation(t *testing.T) {
+	var v config
+	client := staticClient{"/app/Email": "ops@example.com", "/app/Port": "8080"}
+	if err := ssmconfig.NewRequest(&v, "/app", client, WithValidation(nil)).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	client = staticClient{"/app/Email": "not an address", "/app/Port": "0"}
+	err := ssmconfig.NewRequest(&v, "/app", client, WithValidation(nil)).Send(context.Background())
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) || len(invalid) != 2 {
+		t.Fatalf("expected 2 validation errors, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ssmyaml adds support for YAML-valued parameters to ssmconfig
+// without making the root package depend on a YAML library.
+package ssmyaml
+
+import (
+	"github.com/retailnext/ssmconfig"
+	"gopkg.in/yaml.v3"
+)
+
+// Modifier is the ssm tag modifier that marks a field as YAML-valued, e.g.
+// `ssm:"Limits,yaml"`.
+const Modifier = "yaml"
+
+// WithYAML decodes fields tagged with Modifier using yaml.Unmarshal.
+func WithYAML() ssmconfig.Option {
+	return ssmconfig.WithDecoder(Modifier, func(value string, target interface{}) error {
+		return yaml.Unmarshal([]byte(value), target)
+	})
+}
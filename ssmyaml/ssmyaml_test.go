@@ -0,0 +1,75 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmyaml
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/retailnext/ssmconfig"
+)
+
+type staticClient map[string]string
+
+func (c staticClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	var output ssm.GetParametersByPathOutput
+	for name, value := range c {
+		if strings.HasPrefix(name, *params.Path+"/") {
+			output.Parameters = append(output.Parameters, types.Parameter{
+				Name:  aws.String(name),
+				Value: aws.String(value),
+			})
+		}
+	}
+	return &output, nil
+}
+
+type limits struct {
+	CPU    string   `yaml:"cpu"`
+	Memory string   `yaml:"memory"`
+	Zones  []string `yaml:"zones"`
+}
+
+func TestWithYAML(t *testing.T) {
+	var v struct {
+		Limits limits `ssm:"Limits,yaml"`
+	}
+	client := staticClient{"/app/Limits": "cpu: 500m\nmemory: 1Gi\nzones: [a, b]\n"}
+	if err := ssmconfig.NewRequest(&v, "/app", client, WithYAML()).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expected := limits{CPU: "500m", Memory: "1Gi", Zones: []string{"a", "b"}}
+	if !reflect.DeepEqual(v.Limits, expected) {
+		t.Fatalf("unexpected result: %+v", v.Limits)
+	}
+}
+
+func TestWithYAMLParseError(t *testing.T) {
+	var v struct {
+		Limits limits `ssm:"Limits,yaml"`
+	}
+	client := staticClient{"/app/Limits": "cpu: [unterminated"}
+	err := ssmconfig.NewRequest(&v, "/app", client, WithYAML()).Send(context.Background())
+	var parseErrors ssmconfig.ParseErrors
+	if !errors.As(err, &parseErrors) || parseErrors[0].Name != "/app/Limits" {
+		t.Fatalf("expected parse error for /app/Limits, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// applyFetchStrategy routes the names the fetch by path would return to the
+// fetch by name, as WithFetchStrategy asks.
+func (r *request) applyFetchStrategy(path string) error {
+	if r.config.fetchStrategy == FetchByPath || r.config.jsonBundle != "" || r.client == nil {
+		return nil
+	}
+	names, err := r.pathNames(path)
+	if r.config.fetchStrategy == FetchAuto {
+		_, batch := r.client.(GetParametersAPIClient)
+		if err != nil || !batch || len(names)+len(r.byName) > getParametersBatchSize {
+			return nil
+		}
+	} else if err != nil {
+		return err
+	}
+	for _, name := range names {
+		r.byName[name] = struct{}{}
+	}
+	return nil
+}
+
+// pathNames returns the names the fetch by path would fetch, or an error if
+// fetching them by name would tell apart parameters it doesn't.
+func (r *request) pathNames(path string) ([]string, error) {
+	if r.collectsUnmatched() {
+		return nil, errors.New("interface, indexed and map fields can't be fetched by name")
+	}
+	if len(r.input.ParameterFilters) > 0 {
+		return nil, errors.New("ParameterFilters can't be applied to a fetch by name")
+	}
+	var names []string
+	for name := range r.setters {
+		_, byName := r.byName[name]
+		_, external := r.external[name]
+		if byName || external {
+			continue
+		}
+		if !underPath(path, name, aws.ToBool(r.input.Recursive)) {
+			return nil, fmt.Errorf("%s lies below %s, which isn't fetched recursively", name, path)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
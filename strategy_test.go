@@ -0,0 +1,119 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// strategyClient counts the calls made by path alongside the batches.
+type strategyClient struct {
+	batchingClient
+	pathCalls int
+}
+
+func (c *strategyClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	c.pathCalls++
+	return c.fakeClient.GetParametersByPath(ctx, params, optFns...)
+}
+
+func TestWithFetchStrategy(t *testing.T) {
+	type config struct {
+		Foo     string `ssm:"Foo"`
+		Port    int    `ssm:"Port"`
+		Missing string `ssm:"Missing"`
+		Deep    string `ssm:"Deep,path=/app/nested/Deep,optional"`
+	}
+	parameters := map[string]string{
+		"/app/Foo":         "foo",
+		"/app/Port":        "many",
+		"/app/Other":       "other",
+		"/app/nested/Deep": "deep",
+	}
+	var errs []string
+	for _, strategy := range []FetchStrategy{FetchByPath, FetchByName, FetchAuto} {
+		client := &strategyClient{batchingClient: batchingClient{fakeClient: fakeClient{parameters: parameters}}}
+		var v config
+		err := NewRequest(&v, "/app", client, WithFetchStrategy(strategy)).Send(context.Background())
+		if err == nil {
+			t.Fatalf("strategy %d: expected an error", strategy)
+		}
+		errs = append(errs, err.Error())
+		if v != (config{Foo: "foo", Deep: "deep"}) {
+			t.Errorf("strategy %d: unexpected result: %+v", strategy, v)
+		}
+		wantPath, wantBatches := 0, [][]string{{"/app/Foo", "/app/Missing", "/app/Port", "/app/nested/Deep"}}
+		if strategy == FetchByPath {
+			wantPath, wantBatches = 1, [][]string{{"/app/nested/Deep"}}
+		}
+		if client.pathCalls != wantPath || !reflect.DeepEqual(client.batches, wantBatches) {
+			t.Errorf("strategy %d: got %d calls by path and batches %v", strategy, client.pathCalls, client.batches)
+		}
+	}
+	if errs[1] != errs[0] || errs[2] != errs[0] {
+		t.Errorf("strategies disagree: %q", errs)
+	}
+}
+
+func TestFetchAutoFallsBackToPath(t *testing.T) {
+	client := &strategyClient{batchingClient: batchingClient{fakeClient: fakeClient{parameters: map[string]string{}}}}
+	req := NewRequest(&hasTags{}, "/app", client, WithFetchStrategy(FetchAuto))
+	for i := 0; i < getParametersBatchSize; i++ {
+		req.AddParameter("/app/extra"+strconv.Itoa(i), func(string) {})
+	}
+	_ = req.Send(context.Background())
+	if client.pathCalls != 1 {
+		t.Errorf("expected a fetch by path past %d names, got %d", getParametersBatchSize, client.pathCalls)
+	}
+
+	plain := struct{ PathFetcher }{&fakeClient{parameters: map[string]string{"/app/Foo": "foo"}}}
+	var v hasTags
+	if err := NewRequest(&v, "/app", plain, WithFetchStrategy(FetchAuto)).Send(context.Background()); err != nil || v.Foo != "foo" {
+		t.Errorf("expected a fetch by path without GetParameters, got %v", err)
+	}
+
+	var indexed struct {
+		Admins []string `ssm:"admins,indexed"`
+	}
+	client = &strategyClient{batchingClient: batchingClient{fakeClient: fakeClient{parameters: map[string]string{"/app/admins/0": "ada"}}}}
+	if err := NewRequest(&indexed, "/app", client, WithFetchStrategy(FetchAuto)).Send(context.Background()); err != nil || client.pathCalls != 1 {
+		t.Errorf("expected indexed fields to be fetched by path, got %v", err)
+	}
+}
+
+func TestFetchByNameUnsupported(t *testing.T) {
+	client := &strategyClient{}
+	var indexed struct {
+		Admins []string `ssm:"admins,indexed"`
+	}
+	if _, err := TryNewRequest(&indexed, "/app", client, WithFetchStrategy(FetchByName)); err == nil {
+		t.Error("expected an error for an indexed field")
+	}
+	var nested struct {
+		Host string `ssm:"db/host"`
+	}
+	if _, err := TryNewRequest(&nested, "/app", client, WithFetchStrategy(FetchByName)); err == nil {
+		t.Error("expected an error for a name below a path not fetched recursively")
+	}
+	pathOnly := struct{ PathFetcher }{&fakeClient{}}
+	if _, err := TryNewRequest(&hasTags{}, "/app", pathOnly, WithFetchStrategy(FetchByName)); err == nil {
+		t.Error("expected an error for a client that can't fetch by name")
+	}
+}
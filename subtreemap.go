@@ -0,0 +1,137 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// subtreeMap is a map[string]T field for a non-string T, built from the
+// children of name: name/key sets the element key, decoded as a field of
+// type T with the same tag would be.
+type subtreeMap struct {
+	name   string
+	field  reflect.Value
+	tag    fieldTag
+	config *requestConfig
+}
+
+func (r *request) bindSubtreeMap(f reflect.Value, name, fieldPath string, t fieldTag) error {
+	if f.Type().Key().Kind() != reflect.String {
+		return errors.New("map field requires string keys")
+	}
+	m := subtreeMap{name: name, field: f, tag: t, config: &r.config}
+	_, kind, err := m.decoder(reflect.New(f.Type().Elem()).Elem())
+	if err != nil {
+		return err
+	}
+	r.bindings = append(r.bindings, binding{fieldPath: fieldPath, name: name, kind: "map:" + kind})
+	if !t.optional {
+		r.missing[name] = struct{}{}
+	}
+	r.maps = append(r.maps, m)
+	return nil
+}
+
+func (m subtreeMap) decoder(elem reflect.Value) (func(string) error, string, error) {
+	switch elem.Kind() {
+	case reflect.Map:
+		return nil, "", errors.New("map field of maps")
+	case reflect.String:
+		// A map[string]string still takes a single parameter of key=value
+		// pairs, so a forgotten kv modifier isn't read as a subtree.
+		return nil, "", errors.New("map of strings requires the kv modifier")
+	}
+	setter, kind, err := newSetter(elem, m.tag, m.config)
+	if err != nil {
+		return nil, "", err
+	}
+	return setter, kind, nil
+}
+
+// isSubtreeMap reports whether the map field f is built from subtrees rather
+// than decoded from one parameter, by the kv modifier, a decoder or its own
+// Setter.
+func isSubtreeMap(f reflect.Value, t fieldTag, config *requestConfig) bool {
+	if f.Kind() != reflect.Map || t.has("kv") {
+		return false
+	}
+	for modifier := range config.decoders {
+		if t.has(modifier) {
+			return false
+		}
+	}
+	switch f.Addr().Interface().(type) {
+	case Setter, flag.Value:
+		return false
+	}
+	return true
+}
+
+// collectsUnmatched reports whether any field is built from the parameters
+// without a setter of their own, so that fetch must keep them.
+func (r *request) collectsUnmatched() bool {
+	return len(r.subtrees)+len(r.indexed)+len(r.maps) > 0
+}
+
+// resolveMaps builds every map field from the parameters that had no setter
+// during pagination, reporting a ParseError per element that doesn't
+// decode. A map with no elements is left as it was.
+func (r *request) resolveMaps(unmatched map[string]string, parseErrors ParseErrors) ParseErrors {
+	for _, m := range r.maps {
+		prefix := m.name + "/"
+		mv := reflect.MakeMap(m.field.Type())
+		elem := reflect.New(m.field.Type().Elem()).Elem()
+		setter, _, _ := m.decoder(elem)
+		found, failed := false, false
+		for _, name := range sortedKeys(unmatched) {
+			key := strings.TrimPrefix(name, prefix)
+			if key == name || strings.Contains(key, "/") {
+				continue
+			}
+			found = true
+			elem.Set(reflect.Zero(elem.Type()))
+			if err := r.set(setter, unmatched[name]); err != nil {
+				parseErrors = append(parseErrors, &ParseError{Name: name, Err: err})
+				failed = true
+				continue
+			}
+			mv.SetMapIndex(reflect.ValueOf(key).Convert(m.field.Type().Key()), elem)
+		}
+		if !found {
+			continue
+		}
+		r.fetched[m.name] = struct{}{}
+		delete(r.missing, m.name)
+		m.field.Set(mv)
+		if !failed {
+			r.resolved[m.name] = struct{}{}
+		}
+	}
+	return parseErrors
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
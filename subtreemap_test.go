@@ -0,0 +1,128 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSubtreeMap(t *testing.T) {
+	type limits struct {
+		Quotas   map[string]int  `ssm:"quotas"`
+		Features map[string]bool `ssm:"features,optional"`
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/quotas/reads":        "10",
+		"/app/quotas/writes":       "2",
+		"/app/quotas/nested/deep":  "ignored",
+		"/app/features/beta":       "true",
+		"/app/features/dark-mode":  "false",
+		"/app/other/quotas/reads":  "ignored",
+		"/app/quotasreads":         "ignored",
+		"/bad/quotas/reads":        "many",
+		"/bad/quotas/writes":       "3",
+		"/bad/features/beta":       "yes",
+		"/bad/features/dark-mode":  "true",
+		"/bad/features/other/deep": "ignored",
+	}}
+	var v limits
+	req := NewRequest(&v, "/app", client)
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expected := limits{
+		Quotas:   map[string]int{"reads": 10, "writes": 2},
+		Features: map[string]bool{"beta": true, "dark-mode": false},
+	}
+	if !reflect.DeepEqual(v, expected) {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+	if resolved := req.Resolved(); !reflect.DeepEqual(resolved, []string{"/app/features", "/app/quotas"}) {
+		t.Fatalf("unexpected resolved names: %v", resolved)
+	}
+
+	v = limits{}
+	err := NewRequest(&v, "/bad", client).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 2 ||
+		parseErrors[0].Name != "/bad/features/beta" || parseErrors[1].Name != "/bad/quotas/reads" {
+		t.Fatalf("expected a parse error per key, got %v", err)
+	}
+	if !reflect.DeepEqual(v.Quotas, map[string]int{"writes": 3}) || !reflect.DeepEqual(v.Features, map[string]bool{"dark-mode": true}) {
+		t.Fatalf("expected the keys that parsed to be set: %+v", v)
+	}
+
+	v = limits{}
+	err = NewRequest(&v, "/empty", client).Send(context.Background())
+	var missing MissingParameters
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/empty/quotas"}) {
+		t.Fatalf("expected /empty/quotas to be missing, got %v", err)
+	}
+}
+
+func TestSubtreeMapRawJSON(t *testing.T) {
+	var v struct {
+		Flags map[string]json.RawMessage `ssm:"flags"`
+	}
+	client := &fakeClient{parameters: map[string]string{
+		"/app/flags/beta":    `{"rollout": 0.5}`,
+		"/app/flags/regions": `["us", "eu"]`,
+		"/bad/flags/beta":    `{"rollout": 0.5}`,
+		"/bad/flags/broken":  `{"rollout":`,
+	}}
+	if err := NewRequest(&v, "/app", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]json.RawMessage{
+		"beta":    json.RawMessage(`{"rollout": 0.5}`),
+		"regions": json.RawMessage(`["us", "eu"]`),
+	}
+	if !reflect.DeepEqual(v.Flags, expected) {
+		t.Fatalf("unexpected flags: %s", v.Flags)
+	}
+
+	v.Flags = nil
+	if err := NewRequest(&v, "/bad", client).Send(context.Background()); err != nil {
+		t.Fatalf("expected values to be kept unchecked, got %v", err)
+	}
+	if string(v.Flags["broken"]) != `{"rollout":` {
+		t.Fatalf("unexpected flags: %s", v.Flags)
+	}
+
+	v.Flags = nil
+	err := NewRequest(&v, "/bad", client, WithRawJSONValidation()).Send(context.Background())
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 1 || parseErrors[0].Name != "/bad/flags/broken" {
+		t.Fatalf("expected a parse error for /bad/flags/broken, got %v", err)
+	}
+	if !reflect.DeepEqual(v.Flags, map[string]json.RawMessage{"beta": json.RawMessage(`{"rollout": 0.5}`)}) {
+		t.Fatalf("expected the valid keys to be set: %s", v.Flags)
+	}
+}
+
+func TestSubtreeMapUnsupported(t *testing.T) {
+	var v struct {
+		Limits map[string]struct{} `ssm:"limits"`
+	}
+	expectPanic(t, func() { NewRequest(&v, "/app", &fakeClient{}) })
+	var w struct {
+		Limits map[int]string `ssm:"limits"`
+	}
+	expectPanic(t, func() { NewRequest(&w, "/app", &fakeClient{}) })
+}
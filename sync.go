@@ -0,0 +1,183 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// DeleteParametersAPIClient is the subset of *ssm.Client used by SyncConfig
+// to delete orphaned parameters.
+type DeleteParametersAPIClient interface {
+	DeleteParameters(ctx context.Context, params *ssm.DeleteParametersInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParametersOutput, error)
+}
+
+// SyncAPIClient is the subset of *ssm.Client used by SyncConfig.
+type SyncAPIClient interface {
+	PathFetcher
+	PutParameterAPIClient
+	DeleteParametersAPIClient
+}
+
+var _ SyncAPIClient = (*ssm.Client)(nil)
+
+// SyncSummary reports the parameter names SyncConfig acted on.
+type SyncSummary struct {
+	Created   []string
+	Updated   []string
+	Unchanged []string
+	// Orphaned parameters exist under the path but aren't bound to any
+	// field. They're deleted only with WithDeleteOrphans.
+	Orphaned []string
+	Deleted  []string
+}
+
+// deleteParametersBatchSize is the most names DeleteParameters accepts.
+const deleteParametersBatchSize = 10
+
+// SyncConfig reconciles the parameters under path with configurable: it
+// writes the fields whose parameters are absent or differ, and finds the
+// parameters under path that no field is bound to, deleting them only when
+// WithDeleteOrphans is given. WithSkipZeroOnWrite, WithNamePrefix and
+// WithNameSuffix are honored as in WriteConfig; under WithNameSuffix, only
+// the parameters with that suffix can be orphans.
+func SyncConfig(ctx context.Context, configurable interface{}, path string, client SyncAPIClient, opts ...Option) (SyncSummary, error) {
+	config := newRequestConfig(opts)
+	var summary SyncSummary
+	path, err := config.expandPath(normalizeName(path))
+	if err != nil {
+		return summary, err
+	}
+	path = config.prefixed(path)
+	fields, err := writableFields(configurable, path, &config)
+	if err != nil {
+		return summary, err
+	}
+
+	existing := make(map[string]struct{ value, parameterType string })
+	input := ssm.GetParametersByPathInput{
+		Path:           &path,
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	}
+	paginator := ssm.NewGetParametersByPathPaginator(client, &input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return summary, err
+		}
+		for _, parameter := range page.Parameters {
+			existing[normalizeName(aws.ToString(parameter.Name))] = struct{ value, parameterType string }{
+				aws.ToString(parameter.Value),
+				string(parameter.Type),
+			}
+		}
+	}
+
+	if err := getOutsidePath(ctx, client, path, fields, existing); err != nil {
+		return summary, err
+	}
+
+	bound := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		bound[f.name] = struct{}{}
+		if config.skipZeroOnWrite && f.zero && f.tag.optional {
+			continue
+		}
+		current, ok := existing[f.name]
+		switch {
+		case !ok:
+			summary.Created = append(summary.Created, f.name)
+		case !f.overwrite():
+			summary.Unchanged = append(summary.Unchanged, f.name)
+			continue
+		case current.value != f.value || current.parameterType != string(f.parameterType()):
+			summary.Updated = append(summary.Updated, f.name)
+		default:
+			summary.Unchanged = append(summary.Unchanged, f.name)
+			continue
+		}
+		if err := f.put(ctx, client); err != nil {
+			return summary, err
+		}
+	}
+
+	orphans := make(map[string]struct{})
+	for name := range existing {
+		if !strings.HasSuffix(name, config.nameSuffix) {
+			// Another mirror's parameter.
+			continue
+		}
+		if _, ok := bound[name]; !ok {
+			orphans[name] = struct{}{}
+		}
+	}
+	summary.Orphaned = sortedNames(orphans)
+
+	if config.deleteOrphans {
+		for start := 0; start < len(summary.Orphaned); start += deleteParametersBatchSize {
+			end := start + deleteParametersBatchSize
+			if end > len(summary.Orphaned) {
+				end = len(summary.Orphaned)
+			}
+			output, err := client.DeleteParameters(ctx, &ssm.DeleteParametersInput{
+				Names: summary.Orphaned[start:end],
+			})
+			if err != nil {
+				return summary, fmt.Errorf("deleting ssm parameters: %w", err)
+			}
+			summary.Deleted = append(summary.Deleted, output.DeletedParameters...)
+		}
+	}
+
+	return summary, nil
+}
+
+// getOutsidePath adds the fields named outside path by a path modifier to
+// existing, which the fetch by path doesn't return, with GetParameter.
+func getOutsidePath(ctx context.Context, client SyncAPIClient, path string, fields []writableField, existing map[string]struct{ value, parameterType string }) error {
+	for _, f := range fields {
+		if underPath(path, f.name, true) {
+			continue
+		}
+		getter, ok := client.(GetParameterAPIClient)
+		if !ok {
+			return fmt.Errorf("comparing %s, outside %s, requires a client implementing GetParameter", f.name, path)
+		}
+		output, err := getter.GetParameter(ctx, &ssm.GetParameterInput{
+			Name:           aws.String(f.name),
+			WithDecryption: aws.Bool(true),
+		})
+		var notFound *types.ParameterNotFound
+		if errors.As(err, &notFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("reading ssm parameter %s: %w", f.name, err)
+		}
+		existing[f.name] = struct{ value, parameterType string }{
+			aws.ToString(output.Parameter.Value),
+			string(output.Parameter.Type),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+type syncClient struct {
+	fakeClient
+	putClient
+	deleteCalls int
+}
+
+func (c *syncClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	c.parameters[aws.ToString(params.Name)] = aws.ToString(params.Value)
+	return c.putClient.PutParameter(ctx, params, optFns...)
+}
+
+func (c *syncClient) DeleteParameters(ctx context.Context, params *ssm.DeleteParametersInput, optFns ...func(*ssm.Options)) (*ssm.DeleteParametersOutput, error) {
+	c.deleteCalls++
+	var output ssm.DeleteParametersOutput
+	for _, name := range params.Names {
+		if _, ok := c.parameters[name]; ok {
+			delete(c.parameters, name)
+			output.DeletedParameters = append(output.DeletedParameters, name)
+		} else {
+			output.InvalidParameters = append(output.InvalidParameters, name)
+		}
+	}
+	return &output, nil
+}
+
+func TestSyncConfig(t *testing.T) {
+	client := &syncClient{fakeClient: fakeClient{parameters: map[string]string{
+		"/app/Foo":        "old",
+		"/app/Old":        "stale",
+		"/app/Nested/Old": "stale",
+	}}}
+	v := hasTags{Foo: "new", OptionalBar: "bar"}
+	summary, err := SyncConfig(context.Background(), &v, "/app", client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := SyncSummary{
+		Created:  []string{"/app/OptionalBar"},
+		Updated:  []string{"/app/Foo"},
+		Orphaned: []string{"/app/Nested/Old", "/app/Old"},
+	}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if client.deleteCalls != 0 || client.parameters["/app/Old"] != "stale" {
+		t.Fatal("expected orphans to be kept without WithDeleteOrphans")
+	}
+
+	summary, err = SyncConfig(context.Background(), &v, "/app", client, WithDeleteOrphans())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(summary.Deleted)
+	expected = SyncSummary{
+		Unchanged: []string{"/app/Foo", "/app/OptionalBar"},
+		Orphaned:  []string{"/app/Nested/Old", "/app/Old"},
+		Deleted:   []string{"/app/Nested/Old", "/app/Old"},
+	}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if len(client.parameters) != 2 {
+		t.Fatalf("unexpected parameters after sync: %v", client.parameters)
+	}
+}
+
+func TestSyncConfigDeletesInBatches(t *testing.T) {
+	client := &syncClient{fakeClient: fakeClient{parameters: map[string]string{"/app/Foo": "foo"}}}
+	for i := 0; i < 25; i++ {
+		client.parameters["/app/orphan"+strconv.Itoa(i)] = "x"
+	}
+	summary, err := SyncConfig(context.Background(), &hasTags{Foo: "foo"}, "/app", client, WithDeleteOrphans(), WithSkipZeroOnWrite())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Deleted) != 25 || client.deleteCalls != 3 {
+		t.Fatalf("expected 25 deletions in 3 calls, got %d in %d", len(summary.Deleted), client.deleteCalls)
+	}
+}
+
+func TestSyncConfigWithoutOverwrite(t *testing.T) {
+	client := &syncClient{fakeClient: fakeClient{parameters: map[string]string{"/app/Seed": "initial"}}}
+	v := struct {
+		Seed string `ssm:"Seed,overwrite=false"`
+	}{Seed: "changed"}
+	summary, err := SyncConfig(context.Background(), &v, "/app", client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Updated) != 0 || len(summary.Unchanged) != 1 || len(client.inputs) != 0 {
+		t.Fatalf("expected /app/Seed to be left alone, got %+v and writes %+v", summary, client.inputs)
+	}
+}
+
+func TestSyncConfigPathOverride(t *testing.T) {
+	client := &syncClient{fakeClient: fakeClient{parameters: map[string]string{
+		"/app/host":      "db.internal",
+		"/common/region": "us-west-2",
+	}}}
+	v := struct {
+		Host   string `ssm:"host"`
+		Region string `ssm:"Region,path=/common/region"`
+	}{Host: "db.internal", Region: "us-west-2"}
+	summary, err := SyncConfig(context.Background(), &v, "/app", client, WithDeleteOrphans())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(summary.Unchanged, []string{"/app/host", "/common/region"}) || len(summary.Created)+len(summary.Orphaned)+len(summary.Deleted) != 0 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+
+	v.Region = "eu-west-1"
+	if summary, err = SyncConfig(context.Background(), &v, "/app", client); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(summary.Updated, []string{"/common/region"}) || client.parameters["/common/region"] != "eu-west-1" {
+		t.Fatalf("expected /common/region to be updated: %+v", summary)
+	}
+}
+
+func TestSyncConfigMirrored(t *testing.T) {
+	client := &syncClient{fakeClient: fakeClient{parameters: map[string]string{
+		"/app/Foo":              "live",
+		"/app/Old":              "live",
+		"/staging/app/Foo":      "live",
+		"/staging/app/Foo-blue": "old",
+		"/staging/app/Old-blue": "stale",
+	}}}
+	v := hasTags{Foo: "new"}
+	summary, err := SyncConfig(context.Background(), &v, "/app", client, WithNamePrefix("/staging"), WithNameSuffix("-blue"), WithDeleteOrphans())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(summary.Updated, []string{"/staging/app/Foo-blue"}) || !reflect.DeepEqual(summary.Created, []string{"/staging/app/OptionalBar-blue"}) {
+		t.Fatalf("expected the mirror to be written: %+v", summary)
+	}
+	if !reflect.DeepEqual(summary.Deleted, []string{"/staging/app/Old-blue"}) {
+		t.Fatalf("expected only the mirror's orphans to be deleted: %+v", summary)
+	}
+	if client.parameters["/app/Foo"] != "live" || client.parameters["/app/Old"] != "live" || client.parameters["/staging/app/Foo"] != "live" {
+		t.Fatalf("expected the live parameters to be left alone: %v", client.parameters)
+	}
+}
+
+func TestSyncConfigPosition(t *testing.T) {
+	client := &syncClient{fakeClient: fakeClient{parameters: map[string]string{
+		"/app/args/0": "db.internal",
+		"/app/args/1": "5432",
+	}}}
+	v := struct {
+		Host string `ssm:"args,position"`
+		Port int    `ssm:"args,position"`
+	}{Host: "db.internal", Port: 6432}
+	summary, err := SyncConfig(context.Background(), &v, "/app", client, WithDeleteOrphans())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(summary.Unchanged, []string{"/app/args/0"}) || !reflect.DeepEqual(summary.Updated, []string{"/app/args/1"}) || len(summary.Created)+len(summary.Deleted) != 0 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if client.parameters["/app/args/0"] != "db.internal" || client.parameters["/app/args/1"] != "6432" {
+		t.Fatalf("unexpected parameters: %v", client.parameters)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/smithy-go"
+)
+
+const (
+	defaultThrottleRetries = 3
+	defaultThrottleDelay   = 100 * time.Millisecond
+	maxThrottleDelay       = 5 * time.Second
+)
+
+var jitter = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// isThrottle reports whether err is SSM refusing a call for its rate.
+func isThrottle(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "ThrottlingException", "TooManyUpdates":
+		return true
+	}
+	return false
+}
+
+// isMissingPath reports whether err is SSM refusing to list a path that
+// doesn't exist.
+func isMissingPath(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "ValidationException", "ParameterNotFound":
+		return true
+	}
+	return false
+}
+
+// nextPage fetches the next page, retrying throttling errors after a random
+// delay of up to the configured delay, doubled on each retry. Spreading the
+// retries out keeps pods started together from being throttled together
+// again.
+func (r *request) nextPage(ctx context.Context, paginator *ssm.GetParametersByPathPaginator) (*ssm.GetParametersByPathOutput, error) {
+	delay := r.config.throttleDelay
+	for retry := 0; ; retry++ {
+		page, err := paginator.NextPage(ctx)
+		if err == nil || retry >= r.config.throttleRetries || !isThrottle(err) {
+			return page, err
+		}
+		jitter.Lock()
+		d := time.Duration(jitter.Int63n(int64(delay) + 1))
+		jitter.Unlock()
+		if r.overBudget(d) {
+			return nil, &BudgetExceededError{Budget: r.config.budget, Err: err}
+		}
+		if err := sleep(ctx, d); err != nil {
+			return nil, err
+		}
+		if delay *= 2; delay > maxThrottleDelay {
+			delay = maxThrottleDelay
+		}
+	}
+}
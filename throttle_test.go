@@ -0,0 +1,110 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/smithy-go"
+)
+
+type throttlingClient struct {
+	fakeClient
+	failures []error
+	calls    int
+}
+
+func (c *throttlingClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	c.calls++
+	if len(c.failures) > 0 {
+		err := c.failures[0]
+		c.failures = c.failures[1:]
+		return nil, err
+	}
+	return c.fakeClient.GetParametersByPath(ctx, params, optFns...)
+}
+
+func TestThrottleRetry(t *testing.T) {
+	throttled := &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+	newClient := func(failures ...error) *throttlingClient {
+		return &throttlingClient{
+			fakeClient: fakeClient{parameters: map[string]string{"/HasTags/Foo": "foo"}},
+			failures:   failures,
+		}
+	}
+	fast := WithThrottleRetry(3, time.Millisecond)
+
+	client := newClient(throttled, &types.TooManyUpdates{})
+	var v hasTags
+	if err := NewRequest(&v, "/HasTags", client, fast).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "foo" || client.calls != 3 {
+		t.Fatalf("unexpected result after %d calls: %+v", client.calls, v)
+	}
+
+	client = newClient(throttled, throttled, throttled, throttled)
+	err := NewRequest(&hasTags{}, "/HasTags", client, fast).Send(context.Background())
+	if !errors.Is(err, throttled) || client.calls != 4 {
+		t.Fatalf("expected throttling error after 4 calls, got %v after %d", err, client.calls)
+	}
+
+	client = newClient(throttled)
+	err = NewRequest(&hasTags{}, "/HasTags", client, WithThrottleRetry(0, 0)).Send(context.Background())
+	if !errors.Is(err, throttled) || client.calls != 1 {
+		t.Fatalf("expected no retries, got %v after %d calls", err, client.calls)
+	}
+
+	denied := errors.New("access denied")
+	client = newClient(denied)
+	err = NewRequest(&hasTags{}, "/HasTags", client).Send(context.Background())
+	if !errors.Is(err, denied) || client.calls != 1 {
+		t.Fatalf("expected no retries of other errors, got %v after %d calls", err, client.calls)
+	}
+}
+
+func TestTreatMissingPathAsEmpty(t *testing.T) {
+	invalid := &smithy.GenericAPIError{Code: "ValidationException", Message: "path doesn't exist"}
+	var v struct {
+		Region string `ssm:"Region,optional"`
+		Zone   string `ssm:"Zone,optional,default=a"`
+	}
+	client := &throttlingClient{failures: []error{invalid}}
+	if err := NewRequest(&v, "/absent", client, WithTreatMissingPathAsEmpty()).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Region != "" || v.Zone != "a" {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	client = &throttlingClient{failures: []error{invalid}}
+	err := NewRequest(&hasTags{}, "/absent", client, WithTreatMissingPathAsEmpty()).Send(context.Background())
+	var missing MissingParameters
+	if !errors.As(err, &missing) || !reflect.DeepEqual([]string(missing), []string{"/absent/Foo"}) {
+		t.Fatalf("expected /absent/Foo to be missing, got %v", err)
+	}
+
+	client = &throttlingClient{failures: []error{invalid}}
+	err = NewRequest(&v, "/absent", client).Send(context.Background())
+	if !errors.Is(err, invalid) {
+		t.Fatalf("expected the ValidationException without the option, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// Tracer is told of each Send of a Request built WithTracer. It's never
+// given a parameter value.
+type Tracer interface {
+	// StartSend is called as Send starts. Send runs with the context it
+	// returns and calls end once it's done, with the error it returns.
+	StartSend(ctx context.Context) (context.Context, func(trace SendTrace, err error))
+	// StartPage is called before each GetParametersByPath call, numbered
+	// from 0 within the Send, which runs with the context it returns. end is
+	// called with the number of parameters on the page, or the error.
+	StartPage(ctx context.Context, page int) (context.Context, func(parameters int, err error))
+}
+
+// SendTrace sums up a Send for a Tracer.
+type SendTrace struct {
+	// Path is the base path, as resolved by WithPathFunc.
+	Path string
+	// Parameters counts the names fetched, and Pages the pages fetched by
+	// path across every attempt made by WithMissingRetry.
+	Parameters int
+	Pages      int
+}
+
+// startPage starts tracing the next page, if the request has a Tracer.
+func (r *request) startPage(ctx context.Context) (context.Context, func(*ssm.GetParametersByPathOutput, error)) {
+	if r.config.tracer == nil {
+		return ctx, func(*ssm.GetParametersByPathOutput, error) {}
+	}
+	ctx, end := r.config.tracer.StartPage(ctx, r.pages)
+	r.pages++
+	return ctx, func(page *ssm.GetParametersByPathOutput, err error) {
+		if err != nil {
+			end(0, err)
+			return
+		}
+		end(len(page.Parameters), nil)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// recordingTracer records the calls made to it as strings.
+type recordingTracer struct {
+	events []string
+}
+
+func (t *recordingTracer) StartSend(ctx context.Context) (context.Context, func(SendTrace, error)) {
+	t.events = append(t.events, "send")
+	return ctx, func(trace SendTrace, err error) {
+		t.events = append(t.events, fmt.Sprintf("end send %+v %v", trace, err))
+	}
+}
+
+func (t *recordingTracer) StartPage(ctx context.Context, page int) (context.Context, func(int, error)) {
+	t.events = append(t.events, fmt.Sprintf("page %d", page))
+	return ctx, func(parameters int, err error) {
+		t.events = append(t.events, fmt.Sprintf("end page %d %v", parameters, err))
+	}
+}
+
+func TestWithTracer(t *testing.T) {
+	client := &fakeClient{parameters: map[string]string{
+		"/app/Foo":         "foo",
+		"/app/OptionalBar": "bar",
+		"/app/Other":       "other",
+	}, pageSize: 2}
+	tracer := &recordingTracer{}
+	var v hasTags
+	if err := NewRequest(&v, "/app", client, WithTracer(tracer)).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"send",
+		"page 0",
+		"end page 2 <nil>",
+		"page 1",
+		"end page 1 <nil>",
+		"end send {Path:/app Parameters:2 Pages:2} <nil>",
+	}
+	if !reflect.DeepEqual(tracer.events, expected) {
+		t.Fatalf("unexpected events: %q", tracer.events)
+	}
+
+	tracer.events = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := NewRequest(&v, "/app", client, WithTracer(tracer)).Send(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	expected = []string{
+		"send",
+		"page 0",
+		"end page 0 context canceled",
+		"end send {Path:/app Parameters:0 Pages:1} " + err.Error(),
+	}
+	if !reflect.DeepEqual(tracer.events, expected) {
+		t.Fatalf("unexpected events: %q", tracer.events)
+	}
+}
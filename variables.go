@@ -0,0 +1,83 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// expandVariables replaces each ${VAR} in s with its value in vars.
+func expandVariables(s string, vars map[string]string) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable in %q", s)
+		}
+		name := s[start+2 : start+end]
+		value, ok := vars[name]
+		if !ok {
+			return "", fmt.Errorf("undefined tag variable %q", name)
+		}
+		b.WriteString(s[:start])
+		b.WriteString(value)
+		s = s[start+end+1:]
+	}
+}
+
+// expandPath expands the variables given to WithTagVariables in the base
+// path of a Request.
+func (c *requestConfig) expandPath(path string) (string, error) {
+	if c.tagVariables == nil {
+		return path, nil
+	}
+	path, err := expandVariables(path, c.tagVariables)
+	if err != nil {
+		return "", err
+	}
+	return normalizeName(path), nil
+}
+
+// expand expands the variables given to WithTagVariables in the name and
+// path modifier of a tag.
+func (t *fieldTag) expand(vars map[string]string) error {
+	if vars == nil {
+		return nil
+	}
+	suffix, err := expandVariables(t.suffix, vars)
+	if err != nil {
+		return err
+	}
+	t.suffix = strings.Trim(suffix, "/")
+	if override, ok := t.modifiers["path"]; ok {
+		if override, err = expandVariables(override, vars); err != nil {
+			return err
+		}
+		// The modifiers are shared with every Request for the type.
+		modifiers := make(map[string]string, len(t.modifiers))
+		for key, value := range t.modifiers {
+			modifiers[key] = value
+		}
+		modifiers["path"] = override
+		t.modifiers = modifiers
+	}
+	return nil
+}
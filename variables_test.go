@@ -0,0 +1,61 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+func TestWithTagVariables(t *testing.T) {
+	type config struct {
+		Host   string `ssm:"${ENV}/db/host"`
+		Region string `ssm:"Region,path=/common/${REGION}/name"`
+		Foo    string `ssm:"Foo"`
+	}
+	client := &recordingClient{fakeClient: fakeClient{parameters: map[string]string{
+		"/app/prod/db/host":        "db.internal",
+		"/common/us-west-2/name":   "Oregon",
+		"/svc-prod/Foo":            "foo",
+		"/svc-prod/prod/db/host":   "svc.internal",
+		"/svc-prod/Region":         "ignored",
+		"/app/${ENV}/db/host":      "ignored",
+		"/common/${REGION}/name":   "ignored",
+		"/app/staging/db/host":     "ignored",
+		"/svc-staging/prod/db/foo": "ignored",
+	}}}
+	vars := map[string]string{"ENV": "prod", "REGION": "us-west-2"}
+	var v config
+	req := NewRequestWithInput(&v, ssm.GetParametersByPathInput{Path: aws.String("/svc-${ENV}"), Recursive: aws.Bool(true)}, client, WithTagVariables(vars))
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v != (config{Host: "svc.internal", Region: "Oregon", Foo: "foo"}) {
+		t.Fatalf("unexpected result: %+v", v)
+	}
+
+	_, err := TryNewRequest(&v, "/app", client, WithTagVariables(map[string]string{"ENV": "prod"}))
+	var fieldErrors FieldErrors
+	if !errors.As(err, &fieldErrors) || len(fieldErrors) != 1 || fieldErrors[0].FieldPath != "Region" {
+		t.Errorf("expected an error for the undefined REGION, got %v", err)
+	}
+	if _, err := TryNewRequest(&hasTags{}, "/app/${ENV", client, WithTagVariables(vars)); err == nil {
+		t.Error("expected an error for an unterminated variable")
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// ParameterChange is the detail of an EventBridge "Parameter Store Change"
+// event, which Parameter Store sends on the default event bus when a
+// parameter is created, updated or deleted, as in:
+//
+//	{
+//	  "source": "aws.ssm",
+//	  "detail-type": "Parameter Store Change",
+//	  "detail": {
+//	    "operation": "Update",
+//	    "name": "/app/db/host",
+//	    "type": "String",
+//	    "description": ""
+//	  }
+//	}
+//
+// The detail unmarshals from JSON as is, whether the event arrives from an
+// EventBridge rule's SQS target or any other subscription.
+type ParameterChange struct {
+	// Operation is "Create", "Update", "Delete" or "LabelParameterVersion".
+	Operation string `json:"operation"`
+	// Name is the name of the parameter, without a version or label.
+	Name string `json:"name"`
+	// Type is "String", "StringList" or "SecureString".
+	Type string `json:"type"`
+}
+
+// Watch calls Refresh once, then applies each change received from changes
+// until ctx is done or changes is closed, returning ctx.Err() or nil. A change
+// to a parameter bound to a field fetches only that parameter with
+// GetParameters, and a change to any other parameter is skipped. A change
+// that Refresh handles by sending a Request instead sends one: a deletion,
+// a parameter under the path of an interface, indexed or map field, or any
+// change after the last refresh failed. Errors are passed to onError, if not
+// nil, and don't stop the watch.
+func (rf *Refresher) Watch(ctx context.Context, changes <-chan ParameterChange, onError func(error)) error {
+	report := func(err error) {
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+	report(rf.Refresh(ctx))
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case change, ok := <-changes:
+			if !ok {
+				return nil
+			}
+			report(rf.apply(ctx, change))
+		}
+	}
+}
+
+func (rf *Refresher) apply(ctx context.Context, change ParameterChange) error {
+	rf.lock.Lock()
+	defer rf.lock.Unlock()
+
+	req, err := TryNewRequest(rf.configurable, rf.path, rf.client, rf.opts...)
+	if err != nil {
+		return err
+	}
+	r := req.(*request)
+	name := normalizeName(change.Name)
+	if _, bound := r.setters[name]; !bound {
+		if name == r.config.jsonBundle || r.collectsUnmatched() && underPath(aws.ToString(r.input.Path), name, aws.ToBool(r.input.Recursive)) {
+			return rf.send(ctx, r)
+		}
+		return nil
+	}
+	if rf.versions == nil || change.Operation == "Delete" || r.collectsUnmatched() || r.config.pathFunc != nil || r.config.jsonBundle != "" {
+		return rf.send(ctx, r)
+	}
+	return rf.update(ctx, r, []string{name})
+}
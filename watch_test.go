@@ -0,0 +1,116 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParameterChangeJSON(t *testing.T) {
+	var event struct {
+		Detail ParameterChange `json:"detail"`
+	}
+	body := `{"source": "aws.ssm", "detail-type": "Parameter Store Change", "detail": {"operation": "Update", "name": "/app/db/host", "type": "String", "description": ""}}`
+	if err := json.Unmarshal([]byte(body), &event); err != nil {
+		t.Fatal(err)
+	}
+	if expected := (ParameterChange{Operation: "Update", Name: "/app/db/host", Type: "String"}); event.Detail != expected {
+		t.Fatalf("unexpected change: %+v", event.Detail)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	client := &versionedClient{
+		fakeClient: fakeClient{parameters: map[string]string{"/HasTags/Foo": "foo", "/HasTags/OptionalBar": "bar"}},
+		versions:   map[string]int64{"/HasTags/Foo": 1, "/HasTags/OptionalBar": 1},
+	}
+	var v hasTags
+	refresher := NewRefresher(&v, "/HasTags", client)
+	changes := make(chan ParameterChange)
+	done := make(chan error)
+	var errs []error
+	go func() {
+		done <- refresher.Watch(context.Background(), changes, func(err error) { errs = append(errs, err) })
+	}()
+
+	changes <- ParameterChange{Operation: "Update", Name: "/Unrelated/Foo"}
+	client.parameters["/HasTags/Foo"] = "foo2"
+	client.versions["/HasTags/Foo"] = 2
+	changes <- ParameterChange{Operation: "Update", Name: "/HasTags/Foo"}
+	close(changes)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if v.Foo != "foo2" || v.OptionalBar != "bar" || client.pathCalls != 1 || !reflect.DeepEqual(client.fetched, [][]string{{"/HasTags/Foo"}}) {
+		t.Fatalf("unexpected watch: %+v after %d path calls and fetching %v", v, client.pathCalls, client.fetched)
+	}
+	if refresher.versions["/HasTags/Foo"] != 2 {
+		t.Fatalf("expected the new version to be recorded, got %v", refresher.versions)
+	}
+}
+
+func TestWatchDeletion(t *testing.T) {
+	client := &versionedClient{
+		fakeClient: fakeClient{parameters: map[string]string{"/HasTags/Foo": "foo", "/HasTags/OptionalBar": "bar"}},
+		versions:   map[string]int64{"/HasTags/Foo": 1, "/HasTags/OptionalBar": 1},
+	}
+	var v hasTags
+	refresher := NewRefresher(&v, "/HasTags", client)
+	if err := refresher.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	delete(client.parameters, "/HasTags/OptionalBar")
+	if err := refresher.apply(context.Background(), ParameterChange{Operation: "Delete", Name: "/HasTags/OptionalBar"}); err != nil {
+		t.Fatal(err)
+	}
+	if client.pathCalls != 2 || len(client.fetched) != 0 {
+		t.Fatalf("expected a full refresh after a deletion, got %d path calls and fetched %v", client.pathCalls, client.fetched)
+	}
+
+	delete(client.parameters, "/HasTags/Foo")
+	err := refresher.apply(context.Background(), ParameterChange{Operation: "Delete", Name: "/HasTags/Foo"})
+	var missing MissingParameters
+	if !errors.As(err, &missing) {
+		t.Fatalf("expected /HasTags/Foo to be missing, got %v", err)
+	}
+
+	// After a failed refresh, even an update is a full one.
+	client.parameters["/HasTags/Foo"] = "foo"
+	if err := refresher.apply(context.Background(), ParameterChange{Operation: "Update", Name: "/HasTags/Foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if client.pathCalls != 4 || len(client.fetched) != 0 {
+		t.Fatalf("expected a full refresh after a failed one, got %d path calls and fetched %v", client.pathCalls, client.fetched)
+	}
+}
+
+func TestWatchCanceled(t *testing.T) {
+	client := &versionedClient{fakeClient: fakeClient{parameters: map[string]string{"/HasTags/Foo": "foo"}}}
+	var v hasTags
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := NewRefresher(&v, "/HasTags", client).Watch(ctx, make(chan ParameterChange), nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
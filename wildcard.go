@@ -0,0 +1,107 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// wildcardPathFunc returns the func Send calls to resolve the * segment of
+// path, or nil if path has none.
+//
+// SSM has no call listing the children of a path, so the func lists every
+// parameter under the part of path before the * with DescribeParameters,
+// and keeps the children under which the rest of path exists. If several
+// do, it picks the one holding the most recently modified parameter, so
+// that a newly deployed version wins over the one it replaces.
+func wildcardPathFunc(path string, client PathFetcher, config *requestConfig) (func(context.Context) (string, error), error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	wildcard := -1
+	for i, segment := range segments {
+		if !strings.Contains(segment, "*") {
+			continue
+		}
+		if segment != "*" {
+			return nil, fmt.Errorf("invalid path %q: * must be a whole segment", path)
+		}
+		if wildcard >= 0 {
+			return nil, fmt.Errorf("invalid path %q: more than one * segment", path)
+		}
+		wildcard = i
+	}
+	if wildcard < 0 {
+		return nil, nil
+	}
+	if config.pathFunc != nil {
+		return nil, fmt.Errorf("path %q has a * segment, which can't be combined with WithPathFunc", path)
+	}
+	describer, ok := client.(ssm.DescribeParametersAPIClient)
+	if !ok {
+		return nil, fmt.Errorf("path %q has a * segment, which needs a client with DescribeParameters", path)
+	}
+
+	prefix := "/" + strings.Join(segments[:wildcard], "/")
+	suffix := strings.Join(segments[wildcard+1:], "/")
+	return func(ctx context.Context) (string, error) {
+		return resolveWildcard(ctx, describer, prefix, suffix)
+	}, nil
+}
+
+func resolveWildcard(ctx context.Context, client ssm.DescribeParametersAPIClient, prefix, suffix string) (string, error) {
+	latest := make(map[string]time.Time)
+	paginator := ssm.NewDescribeParametersPaginator(client, &ssm.DescribeParametersInput{
+		ParameterFilters: []types.ParameterStringFilter{{
+			Key:    aws.String("Path"),
+			Option: aws.String("Recursive"),
+			Values: []string{prefix},
+		}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, metadata := range page.Parameters {
+			rest := strings.TrimPrefix(normalizeName(aws.ToString(metadata.Name)), strings.TrimSuffix(prefix, "/")+"/")
+			child, rest, ok := strings.Cut(rest, "/")
+			if !ok || suffix != "" && !strings.HasPrefix(rest+"/", suffix+"/") {
+				continue
+			}
+			modified := aws.ToTime(metadata.LastModifiedDate)
+			if t, ok := latest[child]; !ok || modified.After(t) {
+				latest[child] = modified
+			}
+		}
+	}
+
+	var picked string
+	for child, modified := range latest {
+		if picked == "" || modified.After(latest[picked]) || modified.Equal(latest[picked]) && child > picked {
+			picked = child
+		}
+	}
+	if picked == "" {
+		return "", errors.New("no parameters match " + joinName(prefix, joinName("*", suffix)))
+	}
+	return joinName(prefix, joinName(picked, suffix)), nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// describingClient answers DescribeParameters with a Path filter from the
+// parameters of its fakeClient, with the given modification times.
+type describingClient struct {
+	fakeClient
+	modified map[string]time.Time
+	calls    int
+}
+
+func (c *describingClient) DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+	c.calls++
+	prefix := strings.TrimSuffix(params.ParameterFilters[0].Values[0], "/") + "/"
+	var output ssm.DescribeParametersOutput
+	for name := range c.parameters {
+		if strings.HasPrefix(name, prefix) {
+			output.Parameters = append(output.Parameters, types.ParameterMetadata{
+				Name:             aws.String(name),
+				LastModifiedDate: aws.Time(c.modified[name]),
+			})
+		}
+	}
+	return &output, nil
+}
+
+func TestWildcardPath(t *testing.T) {
+	now := time.Now()
+	client := &describingClient{
+		fakeClient: fakeClient{parameters: map[string]string{
+			"/app/v1/config/Foo": "old",
+			"/app/v2/config/Foo": "new",
+			"/app/v3/other/Foo":  "unrelated",
+		}},
+		modified: map[string]time.Time{
+			"/app/v1/config/Foo": now.Add(-time.Hour),
+			"/app/v2/config/Foo": now,
+			"/app/v3/other/Foo":  now.Add(time.Hour),
+		},
+	}
+	var v hasTags
+	req := NewRequest(&v, "/app/*/config", client)
+	if err := req.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "new" {
+		t.Errorf("got Foo %q, want the value under the latest version", v.Foo)
+	}
+	if client.calls != 1 {
+		t.Errorf("got %d DescribeParameters calls, want 1", client.calls)
+	}
+
+	client.parameters = map[string]string{"/app/v1/Foo": "only"}
+	v = hasTags{}
+	if err := NewRequest(&v, "/app/*", client).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v.Foo != "only" {
+		t.Errorf("got Foo %q, want only", v.Foo)
+	}
+
+	client.parameters = map[string]string{"/app/Foo": "not under a child"}
+	if err := NewRequest(&v, "/app/*", client).Send(context.Background()); err == nil {
+		t.Error("expected an error when no child matches")
+	}
+}
+
+func TestWildcardPathInvalid(t *testing.T) {
+	var v hasTags
+	for _, path := range []string{"/app/v*", "/app/*/*"} {
+		if _, err := TryNewRequest(&v, path, &describingClient{}); err == nil {
+			t.Errorf("expected an error for %s", path)
+		}
+	}
+	if _, err := TryNewRequest(&v, "/app/*", &fakeClient{}); err == nil {
+		t.Error("expected an error for a client without DescribeParameters")
+	}
+	pathFunc := WithPathFunc(func(context.Context) (string, error) { return "/app", nil })
+	if _, err := TryNewRequest(&v, "/app/*", &describingClient{}, pathFunc); err == nil {
+		t.Error("expected an error combined with WithPathFunc")
+	}
+}
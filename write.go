@@ -0,0 +1,246 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// PutParameterAPIClient is the subset of *ssm.Client used by WriteConfig.
+type PutParameterAPIClient interface {
+	PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+}
+
+var _ PutParameterAPIClient = (*ssm.Client)(nil)
+
+// WriteConfig stores the current value of every ssm-tagged field of
+// configurable under path, overwriting existing parameters. Fields tagged
+// `secure` are written as SecureString, []string fields as StringList and
+// the rest as String. A field tagged
+// `tier=Advanced` (or another ParameterTier) is written in that tier, as
+// values over 4KB must be, and one tagged `overwrite=false` is only written
+// if its parameter doesn't exist yet. Names are mirrored by WithNamePrefix
+// and WithNameSuffix as for NewRequest. Fields tagged with the modifier of a
+// WithSource among opts aren't written, nor are those tagged inlist.
+func WriteConfig(ctx context.Context, configurable interface{}, path string, client PutParameterAPIClient, opts ...Option) error {
+	config := newRequestConfig(opts)
+	path, err := config.expandPath(normalizeName(path))
+	if err != nil {
+		return err
+	}
+	path = config.prefixed(path)
+	fields, err := writableFields(configurable, path, &config)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if config.skipZeroOnWrite && f.zero && f.tag.optional {
+			continue
+		}
+		if err := f.put(ctx, client); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type writableField struct {
+	name  string
+	value string
+	zero  bool
+	// list is whether the field is a []string, written as a StringList.
+	list bool
+	tag  fieldTag
+}
+
+func (f writableField) parameterType() types.ParameterType {
+	if f.tag.has("secure") {
+		return types.ParameterTypeSecureString
+	}
+	if f.list {
+		return types.ParameterTypeStringList
+	}
+	return types.ParameterTypeString
+}
+
+func (f writableField) put(ctx context.Context, client PutParameterAPIClient) error {
+	input := ssm.PutParameterInput{
+		Name:      aws.String(f.name),
+		Value:     aws.String(f.value),
+		Type:      f.parameterType(),
+		Overwrite: aws.Bool(f.overwrite()),
+		Tier:      types.ParameterTier(f.tag.modifiers["tier"]),
+	}
+	_, err := client.PutParameter(ctx, &input)
+	var exists *types.ParameterAlreadyExists
+	if errors.As(err, &exists) && !f.overwrite() {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("writing ssm parameter %s: %w", f.name, err)
+	}
+	return nil
+}
+
+// overwrite reports whether to replace an existing parameter, which is true
+// unless the field is tagged `overwrite=false`.
+func (f writableField) overwrite() bool {
+	overwrite, err := strconv.ParseBool(f.tag.modifiers["overwrite"])
+	return err != nil || overwrite
+}
+
+// checkWriteModifiers checks the modifiers that only apply to writes.
+func checkWriteModifiers(t fieldTag) error {
+	if overwrite, ok := t.modifiers["overwrite"]; ok {
+		if _, err := strconv.ParseBool(overwrite); err != nil {
+			return fmt.Errorf("invalid overwrite %q", overwrite)
+		}
+	}
+	if tier, ok := t.modifiers["tier"]; ok {
+		for _, valid := range types.ParameterTier("").Values() {
+			if types.ParameterTier(tier) == valid {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid tier %q", tier)
+	}
+	return nil
+}
+
+func writableFields(configurable interface{}, path string, config *requestConfig) ([]writableField, error) {
+	v, err := configurableValue(configurable)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []writableField
+	positions := make(map[string]int)
+	for i := 0; i < v.NumField(); i++ {
+		tag := fieldTagOf(v.Type().Field(i), config)
+		if tag == "" {
+			continue
+		}
+		t := parseTag(tag)
+		// A field fetched by a WithSource isn't stored in SSM, where its
+		// value would be copied, unencrypted, out of its source. One tagged
+		// inlist holds a single member of a list it can't write.
+		if isMetadataTag(t) || config.hasSource(t) || t.has("inlist") {
+			continue
+		}
+		f, err := newWritableField(v.Field(i), path, t, config, positions)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field with ssm tag (%v): %s", err, v.Type().Field(i).Name)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// newWritableField resolves the name of the field f tagged t as bindField
+// does, and encodes its value.
+func newWritableField(f reflect.Value, path string, t fieldTag, config *requestConfig, positions map[string]int) (writableField, error) {
+	if err := t.expand(config.tagVariables); err != nil {
+		return writableField{}, err
+	}
+	name, err := config.fieldName(path, t, positions)
+	if err != nil {
+		return writableField{}, err
+	}
+	value, err := encode(f, t)
+	if err != nil {
+		return writableField{}, err
+	}
+	if err := checkWriteModifiers(t); err != nil {
+		return writableField{}, err
+	}
+	list := f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.String
+	return writableField{name: config.suffixed(name), value: value, zero: f.IsZero(), list: list, tag: t}, nil
+}
+
+// encode formats f for storage so that the setter newDecoder chooses for it
+// would read it back.
+func encode(f reflect.Value, tag fieldTag) (string, error) {
+	switch f.Kind() {
+	case reflect.String:
+		return f.String(), nil
+	case reflect.Bool:
+		truthy, falsy, err := tag.boolValues()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case truthy == "":
+			return strconv.FormatBool(f.Bool()), nil
+		case f.Bool():
+			return truthy, nil
+		default:
+			return falsy, nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		base, err := tag.base()
+		if err != nil {
+			return "", err
+		}
+		if base == 0 {
+			base = 10
+		}
+		return strconv.FormatInt(f.Int(), base), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		base, err := tag.base()
+		if err != nil {
+			return "", err
+		}
+		if base == 0 {
+			base = 10
+		}
+		return strconv.FormatUint(f.Uint(), base), nil
+	case reflect.Slice:
+		if f.Type().Elem().Kind() == reflect.Uint8 {
+			return string(f.Bytes()), nil
+		}
+		if f.Type().Elem().Kind() == reflect.String {
+			items := make([]string, f.Len())
+			for i := range items {
+				// A comma would split the item in two when read back.
+				if items[i] = f.Index(i).String(); strings.Contains(items[i], ",") {
+					return "", fmt.Errorf("list item %q contains a comma", items[i])
+				}
+			}
+			return strings.Join(items, ","), nil
+		}
+	case reflect.Map:
+		if tag.has("kv") && f.Type().Key().Kind() == reflect.String && f.Type().Elem().Kind() == reflect.String {
+			pairs := make([]string, 0, f.Len())
+			iter := f.MapRange()
+			for iter.Next() {
+				pairs = append(pairs, iter.Key().String()+"="+iter.Value().String())
+			}
+			sort.Strings(pairs)
+			return strings.Join(pairs, ","), nil
+		}
+	}
+	return "", fmt.Errorf("can't write type %s", f.Type())
+}
@@ -0,0 +1,272 @@
+// Copyright 2022 RetailNext, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssmconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+type putClient struct {
+	inputs []ssm.PutParameterInput
+	err    error
+}
+
+func (c *putClient) PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.inputs = append(c.inputs, *params)
+	return &ssm.PutParameterOutput{Version: 1}, nil
+}
+
+func (c *putClient) written() map[string]ssm.PutParameterInput {
+	written := make(map[string]ssm.PutParameterInput, len(c.inputs))
+	for _, input := range c.inputs {
+		written[aws.ToString(input.Name)] = input
+	}
+	return written
+}
+
+type writable struct {
+	Host     string            `ssm:"host"`
+	Password string            `ssm:"password,secure"`
+	Umask    uint32            `ssm:"umask,base=8"`
+	Opts     map[string]string `ssm:"opts,kv,optional"`
+	Ignored  string
+}
+
+func TestWriteConfig(t *testing.T) {
+	client := &putClient{}
+	v := writable{
+		Host:     "db.internal",
+		Password: "hunter2",
+		Umask:    0o22,
+		Opts:     map[string]string{"b": "2", "a": "1"},
+	}
+	if err := WriteConfig(context.Background(), &v, "/app", client); err != nil {
+		t.Fatal(err)
+	}
+	written := client.written()
+	if len(written) != 4 {
+		t.Fatalf("unexpected writes: %+v", client.inputs)
+	}
+	for name, expected := range map[string]string{
+		"/app/host":     "db.internal",
+		"/app/password": "hunter2",
+		"/app/umask":    "22",
+		"/app/opts":     "a=1,b=2",
+	} {
+		if value := aws.ToString(written[name].Value); value != expected {
+			t.Errorf("expected %s=%q, got %q", name, expected, value)
+		}
+		if !aws.ToBool(written[name].Overwrite) {
+			t.Errorf("expected %s to be overwritten", name)
+		}
+	}
+	if written["/app/password"].Type != types.ParameterTypeSecureString || written["/app/host"].Type != types.ParameterTypeString {
+		t.Fatalf("unexpected parameter types: %+v", written)
+	}
+
+	var roundTrip writable
+	fetch := &fakeClient{parameters: make(map[string]string)}
+	for name, input := range written {
+		fetch.parameters[name] = aws.ToString(input.Value)
+	}
+	if err := NewRequest(&roundTrip, "/app", fetch).Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if roundTrip.Umask != v.Umask || roundTrip.Opts["b"] != "2" || roundTrip.Password != v.Password {
+		t.Fatalf("unexpected round trip: %+v", roundTrip)
+	}
+}
+
+func TestWriteConfigErrors(t *testing.T) {
+	errPut := errors.New("access denied")
+	err := WriteConfig(context.Background(), &hasTags{Foo: "foo"}, "/app", &putClient{err: errPut})
+	if !errors.Is(err, errPut) {
+		t.Fatalf("expected put error, got %v", err)
+	}
+
+	var v struct {
+		DB hostPort `ssm:"DB"`
+	}
+	if err := WriteConfig(context.Background(), &v, "/app", &putClient{}); err == nil {
+		t.Fatal("expected error writing unsupported field")
+	}
+}
+
+func TestWriteConfigBool(t *testing.T) {
+	client := &putClient{}
+	v := struct {
+		Debug bool `ssm:"Debug"`
+		Mode  bool `ssm:"Mode,truthy=enabled,falsy=disabled"`
+	}{Debug: true}
+	if err := WriteConfig(context.Background(), &v, "/app", client); err != nil {
+		t.Fatal(err)
+	}
+	written := client.written()
+	if aws.ToString(written["/app/Debug"].Value) != "true" || aws.ToString(written["/app/Mode"].Value) != "disabled" {
+		t.Fatalf("unexpected writes: %+v", client.inputs)
+	}
+}
+
+func TestWriteConfigTierAndOverwrite(t *testing.T) {
+	client := &putClient{}
+	v := struct {
+		Bundle string `ssm:"Bundle,tier=Advanced"`
+		Seed   string `ssm:"Seed,overwrite=false"`
+	}{Bundle: "-----BEGIN CERTIFICATE-----", Seed: "initial"}
+	if err := WriteConfig(context.Background(), &v, "/app", client); err != nil {
+		t.Fatal(err)
+	}
+	written := client.written()
+	if bundle := written["/app/Bundle"]; bundle.Tier != types.ParameterTierAdvanced || !aws.ToBool(bundle.Overwrite) {
+		t.Fatalf("unexpected write of /app/Bundle: %+v", bundle)
+	}
+	if seed := written["/app/Seed"]; seed.Tier != "" || aws.ToBool(seed.Overwrite) {
+		t.Fatalf("unexpected write of /app/Seed: %+v", seed)
+	}
+
+	seed := struct {
+		Seed string `ssm:"Seed,overwrite=false"`
+	}{Seed: "again"}
+	if err := WriteConfig(context.Background(), &seed, "/app", &putClient{err: &types.ParameterAlreadyExists{}}); err != nil {
+		t.Fatalf("expected an existing parameter to be left alone, got %v", err)
+	}
+
+	for _, tag := range []string{`ssm:"Bundle,tier=Huge"`, `ssm:"Bundle,overwrite=maybe"`} {
+		invalid := reflect.New(reflect.StructOf([]reflect.StructField{
+			{Name: "Bundle", Type: reflect.TypeOf(""), Tag: reflect.StructTag(tag)},
+		}))
+		if err := WriteConfig(context.Background(), invalid.Interface(), "/app", &putClient{}); err == nil {
+			t.Errorf("expected error for %s", tag)
+		}
+	}
+}
+
+func TestWithSkipZeroOnWrite(t *testing.T) {
+	client := &putClient{}
+	v := writable{Host: "db.internal"}
+	if err := WriteConfig(context.Background(), &v, "/app", client, WithSkipZeroOnWrite()); err != nil {
+		t.Fatal(err)
+	}
+	written := client.written()
+	if _, ok := written["/app/opts"]; ok || len(written) != 3 {
+		t.Fatalf("expected only required fields to be written: %+v", client.inputs)
+	}
+	if _, ok := written["/app/password"]; !ok {
+		t.Fatal("expected zero-valued required field to be written")
+	}
+}
+
+func TestWriteConfigPathOverride(t *testing.T) {
+	client := &putClient{}
+	v := struct {
+		Host   string `ssm:"host"`
+		Region string `ssm:"Region,path=/common/region"`
+	}{Host: "db.internal", Region: "us-west-2"}
+	if err := WriteConfig(context.Background(), &v, "/app", client); err != nil {
+		t.Fatal(err)
+	}
+	written := client.written()
+	if len(written) != 2 || aws.ToString(written["/common/region"].Value) != "us-west-2" {
+		t.Fatalf("expected Region to be written to /common/region: %+v", client.inputs)
+	}
+}
+
+func TestWriteConfigTagVariables(t *testing.T) {
+	client := &putClient{}
+	v := struct {
+		Host   string `ssm:"${ENV}/host"`
+		Region string `ssm:"Region,path=/common/${ENV}/region"`
+	}{Host: "db.internal", Region: "us-west-2"}
+	vars := WithTagVariables(map[string]string{"ENV": "prod", "APP": "app"})
+	if err := WriteConfig(context.Background(), &v, "/${APP}", client, vars); err != nil {
+		t.Fatal(err)
+	}
+	written := client.written()
+	if len(written) != 2 || aws.ToString(written["/app/prod/host"].Value) != "db.internal" || aws.ToString(written["/common/prod/region"].Value) != "us-west-2" {
+		t.Fatalf("unexpected writes: %+v", client.inputs)
+	}
+
+	err := WriteConfig(context.Background(), &v, "/app", client, WithTagVariables(map[string]string{}))
+	if err == nil || !strings.Contains(err.Error(), `undefined tag variable "ENV"`) {
+		t.Fatalf("expected an undefined variable, got %v", err)
+	}
+}
+
+func TestWriteConfigMirrored(t *testing.T) {
+	client := &putClient{}
+	v := hasTags{Foo: "foo", OptionalBar: "bar"}
+	if err := WriteConfig(context.Background(), &v, "/app", client, WithNamePrefix("/staging"), WithNameSuffix("-blue")); err != nil {
+		t.Fatal(err)
+	}
+	written := client.written()
+	if len(written) != 2 || aws.ToString(written["/staging/app/Foo-blue"].Value) != "foo" || aws.ToString(written["/staging/app/OptionalBar-blue"].Value) != "bar" {
+		t.Fatalf("unexpected writes: %+v", client.inputs)
+	}
+}
+
+func TestWriteConfigSkipsSources(t *testing.T) {
+	vault := WithSource("secretsmanager", func(context.Context, string) (string, bool, error) { return "", false, nil })
+	v := struct {
+		Host     string `ssm:"db/host"`
+		Password string `ssm:"db/password,secretsmanager"`
+	}{Host: "db.internal", Password: "hunter2"}
+	client := &putClient{}
+	if err := WriteConfig(context.Background(), &v, "/app", client, vault); err != nil {
+		t.Fatal(err)
+	}
+	if written := client.written(); len(written) != 1 || aws.ToString(written["/app/db/host"].Value) != "db.internal" {
+		t.Fatalf("expected only /app/db/host to be written, got %v", written)
+	}
+
+	sync := &syncClient{fakeClient: fakeClient{parameters: map[string]string{}}}
+	summary, err := SyncConfig(context.Background(), &v, "/app", sync, vault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sync.parameters["/app/db/password"]; ok || !reflect.DeepEqual(summary.Created, []string{"/app/db/host"}) {
+		t.Fatalf("expected only /app/db/host to be created, got %+v", summary)
+	}
+}
+
+func TestWriteConfigLists(t *testing.T) {
+	v := struct {
+		Hosts []string `ssm:"hosts"`
+		Beta  bool     `ssm:"beta,inlist=/app/features"`
+	}{Hosts: []string{"a.internal", "b.internal"}, Beta: true}
+	client := &putClient{}
+	if err := WriteConfig(context.Background(), &v, "/app", client); err != nil {
+		t.Fatal(err)
+	}
+	written := client.written()
+	if hosts := written["/app/hosts"]; len(written) != 1 || aws.ToString(hosts.Value) != "a.internal,b.internal" || hosts.Type != types.ParameterTypeStringList {
+		t.Fatalf("expected only the StringList /app/hosts to be written, got %v", written)
+	}
+
+	v.Hosts = []string{"a,b"}
+	if err := WriteConfig(context.Background(), &v, "/app", &putClient{}); err == nil {
+		t.Fatal("expected an error for an item with a comma")
+	}
+}